@@ -0,0 +1,124 @@
+package color
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatInfo describes the highlight verbs in a format string.
+type FormatInfo struct {
+	// Attributes holds the distinct attributes in the highlight verbs in the
+	// order of their first appearance, e.g. "fgRed", "bold" and "reset".
+	Attributes []string
+
+	// Highlights is the number of %h and %{ verbs.
+	Highlights int
+
+	// Specs holds the attributes of each %h verb as they appear between its
+	// delimiters, e.g. "fgRed+bold", in the order of the verbs.
+	Specs []string
+
+	// Resets is the number of %r and %} verbs.
+	Resets int
+
+	// Unbalanced is true if attributes set in the format are never reset,
+	// so they would carry over into whatever is printed next.
+	Unbalanced bool
+
+	errPos int
+}
+
+// addAttribute records the use of the attribute a. Controls, hyperlinks and the
+// default colors leave no attributes set, so they do not change fi.Unbalanced.
+func (fi *FormatInfo) addAttribute(a string) {
+	switch {
+	case a == "reset":
+		fi.Unbalanced = false
+	case controls[a] != "", a == "linkend", strings.HasPrefix(a, "link="),
+		a == "fgDefault", a == "bgDefault", a == "ulcolorDefault":
+	default:
+		fi.Unbalanced = true
+	}
+	for _, a2 := range fi.Attributes {
+		if a2 == a {
+			return
+		}
+	}
+	fi.Attributes = append(fi.Attributes, a)
+}
+
+// SyntaxError describes an error in a highlight verb.
+type SyntaxError struct {
+	Format string // format string that contains the error
+	Offset int    // byte offset in Format of the error or of the unknown attribute
+	Err    string // the error as it appears in the output, e.g. "%!h(BADATTR)"
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("color: %s at offset %d in %q", e.Err, e.Offset, e.Format)
+}
+
+// Analyze scans the highlight verbs in format without producing any output and returns
+// information about them. The scan stops at the first error, which is returned as a
+// *SyntaxError along with the information gathered up to it.
+func Analyze(format string) (FormatInfo, error) {
+	var info FormatInfo
+	hl := newHighlighter(format, false)
+	defer hl.free()
+	hl.info = &info
+	hl.run()
+	if hl.err != "" {
+		// Drop the extra '%' meant for fmt.
+		return info, &SyntaxError{format, info.errPos, hl.err[1:]}
+	}
+	return info, nil
+}
+
+// CollectStyles analyzes formats and returns how many times each style appears in their
+// %h verbs, e.g. to find slightly different styles used for the same purpose and replace
+// them with a registered style. The styles are normalized so that the same attributes
+// count as the same style whatever their order: the attributes of a verb are sorted and
+// deduplicated and joined with '+', so %h[fgRed+bold] and %h[bold+fgRed] both count as
+// "bold+fgRed". As a reset clears the attributes before it, only the attributes after
+// the last reset in a verb count and "reset" is kept first, e.g. "reset+fgRed". The
+// styles in a format are counted up to its first error, which is returned as a
+// *SyntaxError in errs. The errors are in the order of the formats.
+func CollectStyles(formats ...string) (counts map[string]int, errs []error) {
+	counts = make(map[string]int)
+	for _, f := range formats {
+		info, err := Analyze(f)
+		for _, spec := range info.Specs {
+			counts[normalizeSpec(spec)]++
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return counts, errs
+}
+
+// normalizeSpec returns spec with its attributes after the last reset sorted and
+// deduplicated, preceded by "reset" if spec has one.
+func normalizeSpec(spec string) string {
+	attrs := strings.Split(spec, "+")
+	var prefix string
+	for i := len(attrs) - 1; i >= 0; i-- {
+		if attrs[i] == "reset" {
+			attrs = attrs[i+1:]
+			prefix = "reset"
+			break
+		}
+	}
+	sort.Strings(attrs)
+	norm := attrs[:0]
+	for i, a := range attrs {
+		if i == 0 || a != attrs[i-1] {
+			norm = append(norm, a)
+		}
+	}
+	if prefix != "" {
+		norm = append([]string{prefix}, norm...)
+	}
+	return strings.Join(norm, "+")
+}
@@ -0,0 +1,85 @@
+package color
+
+import (
+	"reflect"
+	"testing"
+)
+
+var analyses = map[string]FormatInfo{
+	"foo %s":                        {},
+	"%h[fgRed+bold]foo%r":           {Attributes: []string{"fgRed", "bold"}, Highlights: 1, Specs: []string{"fgRed+bold"}, Resets: 1},
+	"%h[fgRed]foo%h[bgBlue+fgRed]":  {Attributes: []string{"fgRed", "bgBlue"}, Highlights: 2, Specs: []string{"fgRed", "bgBlue+fgRed"}, Unbalanced: true},
+	"%h[fg#ff0000+reset]%%h[bold]":  {Attributes: []string{"fg#ff0000", "reset"}, Highlights: 1, Specs: []string{"fg#ff0000+reset"}},
+	"%{fgRed}foo %{bold}%s%}%}":     {Attributes: []string{"fgRed", "bold"}, Highlights: 2, Specs: []string{"fgRed", "bold"}, Resets: 2},
+	"%r%h[raw=1;2+bgFill3]foo %d\n": {Attributes: []string{"raw=1;2", "bgFill3"}, Highlights: 1, Specs: []string{"raw=1;2+bgFill3"}, Resets: 1, Unbalanced: true},
+	"%h[bold]%r%h[bell+clearline]%h[link=http://a]a%h[linkend]": {Attributes: []string{"bold", "bell", "clearline", "link=http://a", "linkend"}, Highlights: 4, Specs: []string{"bold", "bell+clearline", "link=http://a", "linkend"}, Resets: 1},
+	"%h[fgRed]%h[fgDefault+bgDefault+clear]":                    {Attributes: []string{"fgRed", "fgDefault", "bgDefault", "clear"}, Highlights: 2, Specs: []string{"fgRed", "fgDefault+bgDefault+clear"}, Unbalanced: true},
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+	for k, v := range analyses {
+		r, err := Analyze(k)
+		if err != nil {
+			t.Errorf("Unexpected error from %q: %v", k, err)
+			continue
+		}
+		if !reflect.DeepEqual(r, v) {
+			t.Errorf("Expected %+v from %q but result was %+v", v, k, r)
+		}
+	}
+}
+
+var analyzeErrors = map[string]SyntaxError{
+	"%h[fgRed]foo%h[fgGdsds]": {Offset: 15, Err: "%!h(BADATTR)"},
+	"foo%h(bold)":             {Offset: 5, Err: "%!h(INVALID)"},
+	"%h[]":                    {Offset: 3, Err: "%!h(MISSING)"},
+	"%h[bold+fgRed":           {Offset: 13, Err: "%!h(SHORT)"},
+	"%{bold}foo%}%}":          {Offset: 14, Err: "%!}(NOBLOCK)"},
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	t.Parallel()
+	for k, v := range analyzeErrors {
+		v.Format = k
+		_, err := Analyze(k)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Expected *SyntaxError from %q but result was %v", k, err)
+			continue
+		}
+		if *serr != v {
+			t.Errorf("Expected %+v from %q but result was %+v", v, k, *serr)
+		}
+	}
+	info, _ := Analyze("%h[fgRed]foo%h[fgGdsds]")
+	if len(info.Attributes) != 1 || info.Highlights != 2 {
+		t.Errorf("Expected the attributes before the error but result was %+v", info)
+	}
+}
+
+func TestCollectStyles(t *testing.T) {
+	t.Parallel()
+	counts, errs := CollectStyles(
+		"%h[fgRed+bold]error:%r %s",
+		"%h[bold+fgRed]fatal:%r %s",
+		"%h[fgRed+bold+bold]x%h[fgBlue]y%r",
+		"%h[bold+reset+fgRed+reset+dim]",
+		"plain %d",
+		"%h[fgRed+bold]foo%h[fgGdsds]",
+	)
+	exp := map[string]int{
+		"bold+fgRed": 4,
+		"fgBlue":     1,
+		"reset+dim":  1,
+	}
+	if !reflect.DeepEqual(counts, exp) {
+		t.Errorf("Expected %v but result was %v", exp, counts)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error but result was %v", errs)
+	}
+	if serr, ok := errs[0].(*SyntaxError); !ok || serr.Format != "%h[fgRed+bold]foo%h[fgGdsds]" {
+		t.Errorf("Expected a *SyntaxError but result was %v", errs[0])
+	}
+}
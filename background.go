@@ -0,0 +1,73 @@
+package color
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BackgroundIsDark reports whether the background of the terminal is dark, e.g. to pick
+// a theme that is readable on it. It first asks the terminal for its background color
+// with an OSC 11 query, waiting a short time for the answer, and then falls back to
+// BackgroundIsDarkEnv. The known result is false if neither determined the background.
+// The query is written to and answered on the controlling terminal, so it must not be
+// used while another goroutine reads from the terminal. The terminal is restored to its
+// previous mode afterwards. It is not supported on Windows.
+func BackgroundIsDark() (dark, known bool) {
+	if resp, ok := queryBackground(); ok {
+		if dark, known = parseOSC11(resp); known {
+			return dark, true
+		}
+	}
+	return BackgroundIsDarkEnv()
+}
+
+// BackgroundIsDarkEnv is the same as BackgroundIsDark but it does not query the terminal.
+// It only reads the COLORFGBG environment variable that some terminals set, e.g. "15;0"
+// for a white foreground on a black background.
+func BackgroundIsDarkEnv() (dark, known bool) {
+	return parseCOLORFGBG(os.Getenv("COLORFGBG"))
+}
+
+// parseCOLORFGBG reports whether the background in the value v of the COLORFGBG
+// environment variable is dark. The background is the last field.
+func parseCOLORFGBG(v string) (dark, known bool) {
+	i := strings.LastIndexByte(v, ';')
+	if i == -1 {
+		return false, false
+	}
+	bg, err := strconv.Atoi(v[i+1:])
+	if err != nil || bg < 0 || bg > 15 {
+		return false, false
+	}
+	// White and the bright colors but bright black are light.
+	return bg != 7 && (bg < 9), true
+}
+
+// parseOSC11 reports whether the background color in resp, the answer of the terminal
+// to an OSC 11 query such as "\x1b]11;rgb:ffff/ffff/ffff\x07", is dark.
+func parseOSC11(resp string) (dark, known bool) {
+	i := strings.Index(resp, "rgb:")
+	if i == -1 {
+		return false, false
+	}
+	resp = resp[i+len("rgb:"):]
+	resp = strings.TrimRight(resp, "\x07\x1b\\")
+	c := strings.Split(resp, "/")
+	if len(c) != 3 {
+		return false, false
+	}
+	var v [3]float64
+	for i, s := range c {
+		if s == "" || len(s) > 4 {
+			return false, false
+		}
+		n, err := strconv.ParseUint(s, 16, 16)
+		if err != nil {
+			return false, false
+		}
+		// Scale to 0-1 according to the number of digits.
+		v[i] = float64(n) / float64(uint64(1)<<(4*uint(len(s)))-1)
+	}
+	return 0.299*v[0]+0.587*v[1]+0.114*v[2] < 0.5, true
+}
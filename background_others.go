@@ -0,0 +1,56 @@
+//go:build !windows
+// +build !windows
+
+package color
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// backgroundTimeout is how long queryBackground waits for the answer of the terminal.
+const backgroundTimeout = 100 * time.Millisecond
+
+// queryBackground asks the controlling terminal for its background color with an
+// OSC 11 query and returns the answer.
+func queryBackground() (string, bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", false
+	}
+	defer tty.Close()
+	fd := int(tty.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return "", false
+	}
+	defer terminal.Restore(fd, state)
+	if _, err = tty.WriteString("\x1b]11;?\x07"); err != nil {
+		return "", false
+	}
+	return readAnswer(tty, backgroundTimeout)
+}
+
+// readAnswer reads the answer of the terminal to a query from tty, an escape sequence
+// terminated by BEL or ST, waiting at most timeout for it.
+func readAnswer(tty *os.File, timeout time.Duration) (string, bool) {
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		// Reading could block forever.
+		return "", false
+	}
+	var resp []byte
+	buf := make([]byte, 64)
+	for len(resp) < 256 {
+		n, err := tty.Read(buf)
+		resp = append(resp, buf[:n]...)
+		if err != nil {
+			return "", false
+		}
+		if n > 0 && (buf[n-1] == '\a' || (buf[n-1] == '\\' && len(resp) > 1 && resp[len(resp)-2] == '\x1b')) {
+			return string(resp), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,45 @@
+package color
+
+import "testing"
+
+var colorfgbgs = map[string][2]bool{
+	"15;0":         {true, true},
+	"0;15":         {false, true},
+	"15;default;0": {true, true},
+	"0;7":          {false, true},
+	"7;8":          {true, true},
+	"0;12":         {false, true},
+	"15;default":   {false, false},
+	"":             {false, false},
+	"0":            {false, false},
+	"0;16":         {false, false},
+}
+
+func TestParseCOLORFGBG(t *testing.T) {
+	t.Parallel()
+	for k, v := range colorfgbgs {
+		if dark, known := parseCOLORFGBG(k); dark != v[0] || known != v[1] {
+			t.Errorf("Expected %v from %q but result was %v", v, k, [2]bool{dark, known})
+		}
+	}
+}
+
+var osc11s = map[string][2]bool{
+	"\x1b]11;rgb:0000/0000/0000\x07":   {true, true},
+	"\x1b]11;rgb:ffff/ffff/ffff\x1b\\": {false, true},
+	"\x1b]11;rgb:fd/f6/e3\x07":         {false, true},
+	"\x1b]11;rgb:2/3/4\x07":            {true, true},
+	"\x1b]11;rgb:ffff/ffff\x07":        {false, false},
+	"\x1b]11;rgb:fffff/0/0\x07":        {false, false},
+	"\x1b]11;rgb:gg/0/0\x07":           {false, false},
+	"\x1b]11;#000000\x07":              {false, false},
+}
+
+func TestParseOSC11(t *testing.T) {
+	t.Parallel()
+	for k, v := range osc11s {
+		if dark, known := parseOSC11(k); dark != v[0] || known != v[1] {
+			t.Errorf("Expected %v from %q but result was %v", v, k, [2]bool{dark, known})
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package color
+
+// queryBackground is not supported as the console cannot be queried with escape sequences.
+func queryBackground() (string, bool) {
+	return "", false
+}
@@ -0,0 +1,72 @@
+package color
+
+import (
+	"io"
+	"sync"
+)
+
+// BufferedPrinter is a Printer that writes through a buffer, see Flush.
+type BufferedPrinter struct {
+	*Printer
+	bw *bufferedWriter
+}
+
+// NewBufferedPrinter creates a new BufferedPrinter that writes to w through a buffer of
+// size bytes with color output enabled according to mode. The output of each call is kept
+// together and the buffer is written to w whenever it would overflow, so a single call
+// that prints more than size bytes is written directly. Call Flush to write the rest,
+// which is lost otherwise, including when the program exits with os.Exit. Like any
+// Printer, it is safe for concurrent use, and the clones of its Printer write to the
+// same buffer.
+func NewBufferedPrinter(w io.Writer, mode Mode, size int) *BufferedPrinter {
+	bw := &bufferedWriter{w: w, size: size}
+	return &BufferedPrinter{New(bw, mode.Color(w)), bw}
+}
+
+// Flush writes any buffered output of bp to its underlying writer.
+func (bp *BufferedPrinter) Flush() error {
+	return bp.bw.Flush()
+}
+
+// bufferedWriter buffers writes to w until there are size bytes.
+type bufferedWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	buf  []byte
+	size int
+}
+
+// Write appends p to the buffer and writes the buffer first if p does not fit.
+// It writes p directly if p is larger than the buffer.
+func (bw *bufferedWriter) Write(p []byte) (n int, err error) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if len(bw.buf)+len(p) > bw.size {
+		if err := bw.flush(); err != nil {
+			return 0, err
+		}
+		if len(p) > bw.size {
+			return bw.w.Write(p)
+		}
+	}
+	bw.buf = append(bw.buf, p...)
+	return len(p), nil
+}
+
+// Flush writes the buffer to w.
+func (bw *bufferedWriter) Flush() error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.flush()
+}
+
+// flush writes the buffer to w. The buffer is emptied even if there is an error
+// so that a broken writer does not make it grow forever.
+func (bw *bufferedWriter) flush() error {
+	if len(bw.buf) == 0 {
+		return nil
+	}
+	_, err := bw.w.Write(bw.buf)
+	bw.buf = bw.buf[:0]
+	return err
+}
@@ -0,0 +1,55 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+// countingWriter counts the writes to it.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	return cw.Buffer.Write(p)
+}
+
+func TestBufferedPrinter(t *testing.T) {
+	t.Parallel()
+	var w countingWriter
+	p := NewBufferedPrinter(&w, EnableColor, 32)
+	p.Printf("%h[fgRed]foo%r\n")
+	p.Printf("bar\n")
+	if w.writes != 0 {
+		t.Errorf("Expected 0 writes but result was %d", w.writes)
+	}
+	exp := Highlight("%h[fgRed]foo%r\nbar\n")
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != exp || w.writes != 1 {
+		t.Errorf("Expected %q in 1 write but result was %q in %d", exp, w.String(), w.writes)
+	}
+	w.Reset()
+	w.writes = 0
+	p.Print("01234567890123456789")
+	p.Print("01234567890123456789")
+	if w.String() != "01234567890123456789" || w.writes != 1 {
+		t.Errorf("Expected %q in 1 write but result was %q in %d", "01234567890123456789", w.String(), w.writes)
+	}
+	p.Print("a line that is longer than the buffer")
+	exp = "0123456789012345678901234567890123456789a line that is longer than the buffer"
+	if w.String() != exp || w.writes != 3 {
+		t.Errorf("Expected %q in 3 writes but result was %q in %d", exp, w.String(), w.writes)
+	}
+	w.Reset()
+	p.Clone().Print("foo")
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != "foo" {
+		t.Errorf("Expected %q but result was %q", "foo", w.String())
+	}
+}
@@ -0,0 +1,68 @@
+package color
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	ciDetectionMu sync.RWMutex
+	ciDetection   bool
+)
+
+// ciProviders holds the environment variables set by CI systems whose log viewers
+// render color.
+var ciProviders = [...]string{
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"TRAVIS",
+	"CIRCLECI",
+	"BUILDKITE",
+	"DRONE",
+	"APPVEYOR",
+}
+
+// EnableCIDetection sets whether SupportsColor treats a recognized CI system as capable
+// of color even though its output is not a terminal. A CI system is recognized if the CI
+// environment variable is set along with the variable of a known provider, e.g.
+// GITHUB_ACTIONS or GITLAB_CI. It is disabled by default because not every CI log
+// viewer renders color.
+// If the default Printer uses PerformCheck, it is replaced with one that has color
+// output enabled or disabled accordingly. See SetDefaultOutput.
+func EnableCIDetection(enable bool) {
+	ciDetectionMu.Lock()
+	ciDetection = enable
+	ciDetectionMu.Unlock()
+	stdMu.Lock()
+	defer stdMu.Unlock()
+	if stdMode == PerformCheck {
+		std = New(std.out, stdMode.Color(std.out))
+	}
+}
+
+// SupportsColor returns true if output to f should be colored, that is, if f is a
+// terminal or if CI detection is enabled and the program is running in a recognized
+// CI system. See EnableCIDetection.
+func SupportsColor(f *os.File) bool {
+	if IsTerminal(f) {
+		return true
+	}
+	ciDetectionMu.RLock()
+	enabled := ciDetection
+	ciDetectionMu.RUnlock()
+	return enabled && isColorCI(os.Getenv)
+}
+
+// isColorCI returns true if the environment variables read with getenv
+// show a CI system whose log viewer renders color.
+func isColorCI(getenv func(string) string) bool {
+	if getenv("CI") == "" {
+		return false
+	}
+	for _, v := range ciProviders {
+		if getenv(v) != "" {
+			return true
+		}
+	}
+	return false
+}
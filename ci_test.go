@@ -0,0 +1,27 @@
+package color
+
+import "testing"
+
+var ciEnvs = []struct {
+	env map[string]string
+	exp bool
+}{
+	{map[string]string{}, false},
+	{map[string]string{"CI": "true"}, false},
+	{map[string]string{"GITHUB_ACTIONS": "true"}, false},
+	{map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, true},
+	{map[string]string{"CI": "1", "GITLAB_CI": "true"}, true},
+	{map[string]string{"CI": "true", "JENKINS_URL": "http://ci"}, false},
+}
+
+func TestIsColorCI(t *testing.T) {
+	t.Parallel()
+	for _, tc := range ciEnvs {
+		getenv := func(k string) string {
+			return tc.env[k]
+		}
+		if r := isColorCI(getenv); r != tc.exp {
+			t.Errorf("Expected %t from %v but result was %t", tc.exp, tc.env, r)
+		}
+	}
+}
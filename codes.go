@@ -0,0 +1,13 @@
+package color
+
+// Codes returns the SGR sequences that set the attributes in spec, the list of attributes
+// as it would appear between the brackets of the highlight verb, e.g. "fgRed+bold".
+// The sequences are merged with Minify, so "fgRed+bold" usually becomes "\x1b[31;1m".
+// It is meant for code that takes a prefix and a suffix instead of a format string,
+// use Reset as the suffix.
+func Codes(spec string) (string, error) {
+	if err := checkSpec(spec); err != nil {
+		return "", err
+	}
+	return Minify(Highlight(verb(spec))), nil
+}
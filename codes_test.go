@@ -0,0 +1,24 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/nhooyr/terminfo/caps"
+)
+
+func TestCodes(t *testing.T) {
+	t.Parallel()
+	exp := Minify(exp(ti.Color(caps.Red, -1) + ti.Strings[caps.EnterBoldMode]))
+	r, err := Codes("fgRed+bold")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	for _, spec := range []string{"", "fgGdsds", "bold+"} {
+		if _, err := Codes(spec); err == nil {
+			t.Errorf("Expected error from %q", spec)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package color
+
+import (
+	"context"
+	"fmt"
+)
+
+// contextKey is the key for the color preference in a context.
+type contextKey struct{}
+
+// WithEnabled returns a copy of ctx that carries whether color output is enabled.
+func WithEnabled(ctx context.Context, color bool) context.Context {
+	return context.WithValue(ctx, contextKey{}, color)
+}
+
+// EnabledFromContext returns whether color output is enabled according to ctx.
+// The ok result is false if ctx carries no color preference.
+func EnabledFromContext(ctx context.Context) (color, ok bool) {
+	color, ok = ctx.Value(contextKey{}).(bool)
+	return color, ok
+}
+
+// SprintfContext processes the highlight verbs in format and then returns the result
+// of fmt.Sprintf with the processed format and the other arguments.
+// Whether color output is enabled is taken from ctx. If ctx carries no color preference,
+// the preference of the default Printer is used.
+// It will expand each Format in a to its appropriate string before calling fmt.Sprintf.
+func SprintfContext(ctx context.Context, format string, a ...interface{}) string {
+	color, ok := EnabledFromContext(ctx)
+	if !ok {
+		color = stdPrinter().color
+	}
+	ExpandFormats(color, a)
+	return fmt.Sprintf(Run(format, color), a...)
+}
@@ -0,0 +1,44 @@
+package color
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestEnabledFromContext(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if _, ok := EnabledFromContext(ctx); ok {
+		t.Errorf("Expected no color preference in %v", ctx)
+	}
+	for _, exp := range []bool{true, false} {
+		r, ok := EnabledFromContext(WithEnabled(ctx, exp))
+		if !ok || r != exp {
+			t.Errorf("Expected %v but result was %v", exp, r)
+		}
+	}
+}
+
+func TestSprintfContext(t *testing.T) {
+	t.Parallel()
+	const s = "%h[fgBlue]bar:%r %s"
+	f := Prepare(s)
+	f2 := Prepare("%h[fgWhite]bar")
+	ctx := context.Background()
+	exp := fmt.Sprintf(f.Get(true), f2.Get(true))
+	r := SprintfContext(WithEnabled(ctx, true), s, f2)
+	if r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	exp = fmt.Sprintf(f.Get(false), f2.Get(false))
+	r = SprintfContext(WithEnabled(ctx, false), s, f2)
+	if r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	exp = fmt.Sprintf(f.Get(stdPrinter().color), f2.Get(stdPrinter().color))
+	r = SprintfContext(ctx, s, f2)
+	if r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+}
@@ -0,0 +1,91 @@
+package color
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Diff returns the unified diff in unified with added lines in green, removed lines in
+// red, hunk headers in cyan and the "---" and "+++" file headers in bold. Context lines
+// and everything else is left plain. Each colored line is followed by a reset.
+// The line counts in the hunk headers tell the lines of a hunk apart from file headers,
+// so a removed line that starts with "--" is still red.
+// The color argument dictates whether color output is enabled, if it is not,
+// unified is returned unchanged.
+func Diff(unified string, color bool) string {
+	if !color || tiErr != nil {
+		return unified
+	}
+	var (
+		added   = Highlight(verb("fgGreen"))
+		removed = Highlight(verb("fgRed"))
+		hunk    = Highlight(verb("fgCyan"))
+		header  = Highlight(verb("bold"))
+	)
+	var b bytes.Buffer
+	old, new := 0, 0 // lines left in the current hunk
+	for _, l := range strings.SplitAfter(unified, "\n") {
+		inHunk := old > 0 || new > 0
+		var start string
+		switch {
+		case inHunk && strings.HasPrefix(l, "-"):
+			start = removed
+			old--
+		case inHunk && strings.HasPrefix(l, "+"):
+			start = added
+			new--
+		case inHunk && (strings.HasPrefix(l, " ") || l == "\n" || l == "\r\n"):
+			old--
+			new--
+		case inHunk && strings.HasPrefix(l, "\\"):
+			// "\ No newline at end of file".
+		case strings.HasPrefix(l, "@@"):
+			start = hunk
+			old, new = hunkCounts(l)
+		case strings.HasPrefix(l, "--- ") || strings.HasPrefix(l, "+++ "):
+			start = header
+		default:
+			old, new = 0, 0
+		}
+		writeStyledLine(&b, start, l)
+	}
+	return b.String()
+}
+
+// hunkCounts returns the number of old and new lines in the hunk with the
+// header l, e.g. "@@ -1,3 +1,4 @@". It returns zeros if l is malformed.
+func hunkCounts(l string) (old, new int) {
+	f := strings.Fields(l)
+	if len(f) < 4 || f[0] != "@@" || f[3] != "@@" {
+		return 0, 0
+	}
+	old, ok := rangeCount(f[1], '-')
+	if !ok {
+		return 0, 0
+	}
+	new, ok = rangeCount(f[2], '+')
+	if !ok {
+		return 0, 0
+	}
+	return old, new
+}
+
+// rangeCount returns the line count of a range in a hunk header,
+// e.g. 3 for "-1,3". The count is 1 if it is omitted.
+func rangeCount(r string, sign byte) (int, bool) {
+	if r == "" || r[0] != sign {
+		return 0, false
+	}
+	r = r[1:]
+	i := strings.IndexByte(r, ',')
+	if i == -1 {
+		_, err := strconv.Atoi(r)
+		return 1, err == nil
+	}
+	if _, err := strconv.Atoi(r[:i]); err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(r[i+1:])
+	return n, err == nil && n >= 0
+}
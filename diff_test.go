@@ -0,0 +1,64 @@
+package color
+
+import "testing"
+
+const unified = `diff --git a/foo b/foo
+--- a/foo
++++ b/foo
+@@ -1,3 +1,3 @@ func foo
+ context
+--- removed
++added
+ context
+\ No newline at end of file
+--- a/bar
++++ b/bar
+@@ -1 +1,0 @@
+-gone
+`
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	g, r, c, b, z := Highlight("%h[fgGreen]"), Highlight("%h[fgRed]"), Highlight("%h[fgCyan]"), Highlight("%h[bold]"), reset()
+	if tiErr != nil {
+		g, r, c, b, z = "", "", "", "", ""
+	}
+	exp := "diff --git a/foo b/foo\n" +
+		b + "--- a/foo" + z + "\n" +
+		b + "+++ b/foo" + z + "\n" +
+		c + "@@ -1,3 +1,3 @@ func foo" + z + "\n" +
+		" context\n" +
+		r + "--- removed" + z + "\n" +
+		g + "+added" + z + "\n" +
+		" context\n" +
+		"\\ No newline at end of file\n" +
+		b + "--- a/bar" + z + "\n" +
+		b + "+++ b/bar" + z + "\n" +
+		c + "@@ -1 +1,0 @@" + z + "\n" +
+		r + "-gone" + z + "\n"
+	if res := Diff(unified, true); res != exp {
+		t.Errorf("Expected %q but result was %q", exp, res)
+	}
+	if res := Diff(unified, false); res != unified {
+		t.Errorf("Expected %q but result was %q", unified, res)
+	}
+}
+
+var hunkHeaders = map[string][2]int{
+	"@@ -1,3 +1,4 @@":        {3, 4},
+	"@@ -1 +1 @@ foo\n":      {1, 1},
+	"@@ -0,0 +1,2 @@":        {0, 2},
+	"@@ -1,x +1 @@":          {0, 0},
+	"@@ +1,1 -1,1 @@":        {0, 0},
+	"@@ -1,3 +1,4":           {0, 0},
+	"@@@ -1,3 -1,3 +1,4 @@@": {0, 0},
+}
+
+func TestHunkCounts(t *testing.T) {
+	t.Parallel()
+	for k, v := range hunkHeaders {
+		if old, new := hunkCounts(k); old != v[0] || new != v[1] {
+			t.Errorf("Expected %v from %q but result was %v", v, k, [2]int{old, new})
+		}
+	}
+}
@@ -8,6 +8,30 @@ Verbs:
 	%h[attr...]	replaced with a SGR code that sets all of the attributes in []
 			multiple attributes are + separated
 	%r		an abbreviation for %h[reset]
+	%{attr...}	like %h[attr...] but opens a block that ends at the matching %}
+	%}		ends the innermost block and restores the attributes active at its start
+
+Base Styles:
+
+A reset, including %r, reverts to the base style of a Printer rather than the terminal
+default, see SetBaseStyle. With a base style of "fg245", %h[fgRed]err%r normal prints
+"err" in red and " normal" in color 245 instead of the default color. Without a base
+style, a reset reverts to the terminal default. There is no separate verb for reverting
+to the base style, as %b is already the binary verb of the fmt package.
+
+Blocks:
+
+The attributes of %h carry over until a reset, so a forgotten %r colors everything after
+it. A block scopes them instead: %{fgRed}panic:%} %s prints "panic:" in red and the
+argument in the attributes active before the block. The end of a block restores the attributes that
+were active at its start rather than resetting them, so in %{bold}a %{fgRed}b%} c%}
+"c" is still bold. That is written as a reset followed by the attributes to restore,
+the only way to undo attributes in general. Blocks and the other verbs mix freely:
+%h and %r inside a block change the attributes as usual until the end of the block,
+which restores them regardless, and a %} without a matching %{ is an error. The
+delimiters of a block are always '{' and '}', even after SetVerbDelimiters. A block left
+open at the end of the format is not closed, use the AutoResetEnd flag or Scope to
+guarantee a reset.
 
 Preparing Strings:
 
@@ -27,6 +51,8 @@ If an error occurs, the generated string will contain a description of the probl
 		Printf("%h[fgGdsds]%s", "hi"):		%!h(BADATTR)
 	String ended before the verb:
 		Printf("%h[fg", "hi"):			%!h(SHORT)
+	End of a block that was not opened:
+		Printf("%}%s", "hi"):			%!}(NOBLOCK)
 
 Everything else is handled by the fmt package. You should read its documentation.
 
@@ -49,14 +75,56 @@ Named Colors:
 	%h[xgBrightMagenta]
 	%h[xgBrightCyan]
 	%h[xgBrightWhite]
+	%h[xgDefault]
 
 	Where 'x' is either 'f' or 'b'.
+	xgDefault sets the color back to the terminal default without a reset,
+	so %h[bold+fgDefault] keeps bold on.
 
 256 Colors:
 	%h[fgx]
 	%h[bgx]
 
 	Where x is any number from 0-255.
+	Set the Force16 flag on a Printer to write them as the nearest named color instead.
+
+Grayscale Colors:
+	%h[fggrayx]
+	%h[bggrayx]
+
+	Where x is any number from 0-23, the steps of the grayscale ramp of the 256 colors
+	from near black to near white. See Gray.
+
+Color Cube:
+	%h[fgcube(r,g,b)]
+	%h[bgcube(r,g,b)]
+
+	Where r, g and b are numbers from 0-5, the components of the color in the 6x6x6 color
+	cube of the 256 colors, so %h[fgcube(5,0,0)] is %h[fg196]. See CubeIndex.
+
+Basic Colors by Number:
+	%h[fgansix]
+	%h[bgansix]
+
+	Where x is any number from 0-15. They are written with the 30-37 and 90-97 or the
+	40-47 and 100-107 SGR codes, unlike 256 colors which may use the palette instead.
+
+Background Fill:
+	%h[bgFillx]
+
+	Where x is a color name or a number from 0-255.
+	It sets the background color and then erases to the end of the line, which
+	fills the rest of the line with the background color regardless of the width
+	of the terminal. Use it to highlight whole lines.
+
+True Colors:
+	%h[fg#rrggbb]
+	%h[bg#rrggbb]
+
+	Where rrggbb is the hexadecimal red, green and blue components of the color.
+	The terminal must support true colors, there is no terminfo capability for them.
+	Set the Force256 or Force16 flag on a Printer, or use PrintfLevel, to write them as
+	the nearest 256 or named color instead.
 
 Modes:
 	%h[reset] or the %r verb
@@ -66,6 +134,55 @@ Modes:
 	%h[blink]
 	%h[dim]
 
+	A mode preceded by '-', e.g. %h[-bold], turns the mode off without a reset, so
+	%h[fgRed+-underline] sets the foreground and turns underline off in one verb.
+	-bold and -dim turn off both bold and dim, as they share the SGR code 22.
+
+Underline Styles and Colors:
+	%h[underline=x]
+	%h[ulcolory]
+
+	Where x is one of single, double, curly, dotted and dashed and y is a color name,
+	a number from 0-255, #rrggbb or Default. A plain underline is written before the
+	style for terminals that do not support underline styles.
+
+Control Sequences:
+	%h[bell]
+	%h[clear]
+	%h[clearline]
+
+	bell rings the terminal bell, clear clears the screen and moves the cursor to the
+	top left corner and clearline clears the current line and moves the cursor to its start.
+	They do not change the attributes, so they need no reset. They are independent of
+	color but like the attributes, they are only written when color is enabled.
+
+Hyperlinks:
+	%h[link=x]
+	%h[linkend]
+
+	Where x is a URL without control characters, '+' or the closing delimiter, which
+	must be percent-encoded in x, e.g. '+' as %2B. A '%' in x is written as is.
+	The text after link=x is a hyperlink to x until linkend, written with the OSC 8
+	sequences. Hyperlinks are independent of the attributes: a reset, including %r,
+	does not close the hyperlink and linkend does not change the attributes, so
+	%h[link=https://example.com+fgBlue+underline]example%r.com%h[linkend] links
+	"example.com" but only colors "example". The AutoResetEnd flag closes a hyperlink
+	left open at the end of the format. Like the control sequences, hyperlinks are only
+	written when color is enabled.
+
+Styles:
+	%h[name]
+
+	Where name was registered with RegisterStyle.
+
+Raw Sequences:
+	%h[raw=x]
+
+	Where x is made of digits, semicolons and letters. It is written verbatim as the
+	parameters of a SGR sequence, so %h[raw=1;2;3] becomes "\x1b[1;2;3m".
+	The parameters are not checked in any way, the caller is responsible for their correctness.
+	They are dropped when color is disabled, like every other attribute.
+
 See http://goo.gl/LRLA7o for information on the attributes. Scroll down to the SGR section.
 
 See http://goo.gl/fvtHLs and ISO-8613-3 (according to above document) for more information on 256 colors.
@@ -0,0 +1,247 @@
+package color
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dumpStyles holds the styles of Dump.
+var dumpStyles = [...]struct {
+	name, spec string
+}{
+	dumpKey:   {"dumpKey", "fgBlue+bold"},
+	dumpValue: {"dumpValue", "fgGreen"},
+}
+
+// The kinds of tokens that Dump colors.
+const (
+	dumpKey = iota
+	dumpValue
+)
+
+// Dump writes v to w in the syntax of Go, similar to the %#v verb of the fmt package but
+// with each struct, map, slice and array on multiple lines, indented by two spaces for every
+// level of nesting. Struct field names and map keys are colored with the style registered as
+// dumpKey and the other values with the style registered as dumpValue, or blue and bold and
+// green if they are not registered. Each colored token is followed by a reset.
+//
+// Unexported struct fields are skipped. Pointers are followed and written with a leading '&'.
+// A pointer, map or slice that refers to a value that is already being written is written as
+// "<cycle>" instead.
+// Nil pointers, interfaces, maps and slices are written as nil. Values that implement error
+// or fmt.Stringer are written as the result of their Error or String method. Map entries are
+// written in the order of their keys as written.
+//
+// The color argument dictates whether color output is enabled, if it is not, the output is
+// plain. It returns any write error encountered.
+func Dump(w io.Writer, v interface{}, color bool) error {
+	d := &dumper{visited: make(map[visit]bool)}
+	if color && tiErr == nil {
+		for i, ds := range dumpStyles {
+			d.starts[i] = themedStart(ds.name, ds.spec)
+		}
+		d.reset = reset()
+	}
+	d.dump(reflect.ValueOf(v), 0)
+	d.buf.WriteByte('\n')
+	_, err := w.Write(d.buf.Bytes())
+	return err
+}
+
+// dumper writes values for Dump.
+type dumper struct {
+	buf     bytes.Buffer
+	starts  [len(dumpStyles)]string // SGR sequences of the styles, empty without color
+	reset   string                  // written after a colored token
+	visited map[visit]bool          // pointers, maps and slices being written
+}
+
+// visit identifies a pointer, map or slice. The type and the length tell apart the
+// values that share an address, e.g. a slice and a shorter slice of it.
+type visit struct {
+	p uintptr
+	t reflect.Type
+	n int
+}
+
+// enter records v, a pointer, map or slice, as being written and returns the function
+// that removes it. If v is already being written, it writes "<cycle>" and returns nil.
+func (d *dumper) enter(v reflect.Value) (leave func()) {
+	k := visit{v.Pointer(), v.Type(), 0}
+	if v.Kind() == reflect.Slice {
+		k.n = v.Len()
+	}
+	if d.visited[k] {
+		d.token(dumpValue, "<cycle>")
+		return nil
+	}
+	d.visited[k] = true
+	return func() {
+		delete(d.visited, k)
+	}
+}
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// dump writes v, which is nested depth levels deep.
+func (d *dumper) dump(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.token(dumpValue, "nil")
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		if v.IsNil() {
+			d.token(dumpValue, "nil")
+			return
+		}
+	}
+	if v.CanInterface() {
+		switch {
+		case v.Type().Implements(errorType):
+			d.token(dumpValue, v.Interface().(error).Error())
+			return
+		case v.Type().Implements(stringerType):
+			d.token(dumpValue, v.Interface().(fmt.Stringer).String())
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		leave := d.enter(v)
+		if leave == nil {
+			return
+		}
+		defer leave()
+		d.buf.WriteByte('&')
+		d.dump(v.Elem(), depth)
+	case reflect.Interface:
+		d.dump(v.Elem(), depth)
+	case reflect.Struct:
+		d.buf.WriteString(v.Type().String())
+		d.open()
+		t := v.Type()
+		n := 0
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				// Unexported.
+				continue
+			}
+			d.line(depth + 1)
+			d.token(dumpKey, t.Field(i).Name)
+			d.buf.WriteString(": ")
+			d.dump(v.Field(i), depth+1)
+			d.buf.WriteByte(',')
+			n++
+		}
+		d.close(n, depth)
+	case reflect.Map:
+		leave := d.enter(v)
+		if leave == nil {
+			return
+		}
+		defer leave()
+		d.buf.WriteString(v.Type().String())
+		d.open()
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = scalar(k)
+		}
+		sort.Sort(byName{keys, names})
+		for i, k := range keys {
+			d.line(depth + 1)
+			d.token(dumpKey, names[i])
+			d.buf.WriteString(": ")
+			d.dump(v.MapIndex(k), depth+1)
+			d.buf.WriteByte(',')
+		}
+		d.close(len(keys), depth)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Len() > 0 {
+			leave := d.enter(v)
+			if leave == nil {
+				return
+			}
+			defer leave()
+		}
+		d.buf.WriteString(v.Type().String())
+		d.open()
+		for i := 0; i < v.Len(); i++ {
+			d.line(depth + 1)
+			d.dump(v.Index(i), depth+1)
+			d.buf.WriteByte(',')
+		}
+		d.close(v.Len(), depth)
+	default:
+		d.token(dumpValue, scalar(v))
+	}
+}
+
+// scalar returns v, which is not a struct, map, slice or array, as it is written.
+func scalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return "nil"
+		}
+	}
+	if v.CanInterface() {
+		return fmt.Sprint(v.Interface())
+	}
+	return fmt.Sprint(v)
+}
+
+// byName sorts map keys by their names as written.
+type byName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (b byName) Len() int           { return len(b.keys) }
+func (b byName) Less(i, j int) bool { return b.names[i] < b.names[j] }
+func (b byName) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+}
+
+// token writes s in the style typ.
+func (d *dumper) token(typ int, s string) {
+	if d.starts[typ] == "" {
+		d.buf.WriteString(s)
+		return
+	}
+	d.buf.WriteString(d.starts[typ])
+	d.buf.WriteString(s)
+	d.buf.WriteString(d.reset)
+}
+
+// open writes the opening brace of a struct, map, slice or array.
+func (d *dumper) open() {
+	d.buf.WriteByte('{')
+}
+
+// close writes the closing brace of a struct, map, slice or array with n elements,
+// on its own line if there are any elements.
+func (d *dumper) close(n, depth int) {
+	if n > 0 {
+		d.line(depth)
+	}
+	d.buf.WriteByte('}')
+}
+
+// line starts a new line indented for depth.
+func (d *dumper) line(depth int) {
+	d.buf.WriteByte('\n')
+	d.buf.WriteString(strings.Repeat("  ", depth))
+}
@@ -0,0 +1,113 @@
+package color
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type dumpInner struct {
+	X int
+}
+
+type dumpOuter struct {
+	Name   string
+	Tags   []string
+	Inner  *dumpInner
+	Nil    *dumpInner
+	Counts map[string]int
+	Any    interface{}
+	Err    error
+	Wait   time.Duration
+	Empty  struct{}
+	Next   *dumpOuter
+	hidden int
+}
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+	v := &dumpOuter{
+		Name:   "foo",
+		Tags:   []string{"a", "b"},
+		Inner:  &dumpInner{X: 1},
+		Counts: map[string]int{"b": 2, "a": 1},
+		Err:    errors.New("bad"),
+		Wait:   time.Second,
+		hidden: 3,
+	}
+	v.Next = v
+	const exp = `&color.dumpOuter{
+  Name: "foo",
+  Tags: []string{
+    "a",
+    "b",
+  },
+  Inner: &color.dumpInner{
+    X: 1,
+  },
+  Nil: nil,
+  Counts: map[string]int{
+    "a": 1,
+    "b": 2,
+  },
+  Any: nil,
+  Err: bad,
+  Wait: 1s,
+  Empty: struct {}{},
+  Next: <cycle>,
+}
+`
+	var b bytes.Buffer
+	if err := Dump(&b, v, false); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestDumpCycles(t *testing.T) {
+	t.Parallel()
+	m := map[string]interface{}{}
+	m["self"] = m
+	sl := []interface{}{1, nil}
+	sl[1] = sl
+	const exp = `[]interface {}{
+  map[string]interface {}{
+    "self": <cycle>,
+  },
+  []interface {}{
+    1,
+    <cycle>,
+  },
+  []interface {}{
+    1,
+  },
+}
+`
+	var b bytes.Buffer
+	if err := Dump(&b, []interface{}{m, sl, sl[:1]}, false); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestDumpColor(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := Dump(&b, map[int]bool{1: true}, true); err != nil {
+		t.Fatal(err)
+	}
+	key := Highlight(verb("fgBlue+bold"))
+	val := Highlight(verb("fgGreen"))
+	exp := "map[int]bool{\n  " + key + "1" + reset() + ": " + val + "true" + reset() + ",\n}\n"
+	if tiErr != nil {
+		exp = "map[int]bool{\n  1: true,\n}\n"
+	}
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
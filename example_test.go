@@ -1,6 +1,7 @@
 package color_test
 
 import (
+	"flag"
 	"os"
 
 	"github.com/nhooyr/color"
@@ -70,3 +71,14 @@ func ExamplePrinter() {
 	p = color.New(os.Stderr, false)
 	p.Printfp(redFormat, "bar")
 }
+
+func ExampleMode() {
+	fs := flag.NewFlagSet("example", flag.ContinueOnError)
+	var mode color.Mode
+	fs.Var(&mode, "color", "whether to color output: auto, always or never")
+	fs.Parse([]string{"-color=never"})
+
+	p := color.New(os.Stdout, mode.Color(os.Stdout))
+	p.Printf("%h[fgRed]color is %s%r\n", mode)
+	// Output: color is never
+}
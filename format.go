@@ -7,11 +7,28 @@ import "fmt"
 type Format struct {
 	colored  string // highlight verbs replaced with their escape sequences
 	stripped string // highlight verbs stripped
+	active   bool   // attributes are active at the end of colored
+	verbs    bool   // highlight verbs were processed
 }
 
 // Prepare returns a Format structure using f as the base string.
+// Both the colored and the stripped strings are processed once by Prepare,
+// so Get does no work beyond choosing one of them.
 func Prepare(f string) *Format {
-	return &Format{Highlight(f), Strip(f)}
+	colored, active := process(f, true, "", 0)
+	return &Format{colored, Strip(f), active, HasVerbs(f)}
+}
+
+// HasColor returns true if the base string of f contains any highlight verbs,
+// that is, if the strings returned by f.Get(true) and f.Get(false) could differ.
+func (f *Format) HasColor() bool {
+	return f.verbs
+}
+
+// Active returns true if the colored string of f leaves attributes active at its end,
+// so that a reset is needed after it to keep them from applying to what follows.
+func (f *Format) Active() bool {
+	return f.active
 }
 
 // Get returns the colored string if color is true, and the stripped string otherwise.
@@ -22,17 +39,38 @@ func (f *Format) Get(color bool) string {
 	return f.stripped
 }
 
+// Open returns the escape sequences of f if color is true and an empty string otherwise.
+// It is meant for a Format prepared from a style alone, e.g. Prepare("%h[fgRed+bold]"),
+// whose sequences can be written before content with Close after it. For a Format with
+// anything but highlight verbs in its base string, it always returns an empty string.
+func (f *Format) Open(color bool) string {
+	if !color || !f.verbs || f.stripped != "" {
+		return ""
+	}
+	return f.colored
+}
+
+// Close returns the sequence that resets the attributes left active by f if color
+// is true and an empty string otherwise. See Open.
+func (f *Format) Close(color bool) string {
+	if !color || !f.active || f.stripped != "" {
+		return ""
+	}
+	return reset()
+}
+
 // Eprintfp calls fmt.Sprintf using f's strings and the rest of the arguments.
 // It will expand each Format in a to its appropriate string before calling Sprintf.
 // It then returns the resulting Format.
 func (f *Format) Eprintfp(a ...interface{}) *Format {
+	rf := &Format{active: f.active, verbs: f.verbs}
 	m := make(map[int]*Format)
 	for i, v := range a {
 		if f, ok := v.(*Format); ok {
 			a[i], m[i] = f.Get(true), f
+			rf.verbs = rf.verbs || f.verbs
 		}
 	}
-	rf := new(Format)
 	rf.colored = fmt.Sprintf(f.colored, a...)
 	for i, f := range m {
 		a[i] = f.Get(false)
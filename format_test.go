@@ -57,3 +57,98 @@ func TestExpandFormats(t *testing.T) {
 		t.Errorf("Expected %q but result was %q", exp, r)
 	}
 }
+
+func BenchmarkPrepareNoVerbs(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Prepare(noVerbsString)
+	}
+}
+
+func TestGetMatchesRun(t *testing.T) {
+	t.Parallel()
+	for _, s := range []string{"foo", "%h[fgRed+bold]foo%r %s", "%h[bgFill236]%d%%", "%h[fgRedd]foo", noVerbsString} {
+		f := Prepare(s)
+		if exp, r := Run(s, true), f.Get(true); r != exp {
+			t.Errorf("Expected %q from %q but result was %q", exp, s, r)
+		}
+		if exp, r := Run(s, false), f.Get(false); r != exp {
+			t.Errorf("Expected %q from %q but result was %q", exp, s, r)
+		}
+	}
+}
+
+func BenchmarkFormatGet(b *testing.B) {
+	f := Prepare("%h[fgRed+bold]error:%r %s")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		f.Get(i%2 == 0)
+	}
+}
+
+func TestHasColor(t *testing.T) {
+	t.Parallel()
+	formats := map[string]bool{
+		"%h[fgBlue]foo":  true,
+		"foo%r":          true,
+		"foo %s: %d%%":   false,
+		"%%h[fgBlue]foo": false,
+		"":               false,
+	}
+	for k, v := range formats {
+		if r := Prepare(k).HasColor(); r != v {
+			t.Errorf("Expected %v from %q but result was %v", v, k, r)
+		}
+	}
+	if !Prepare("%s").Eprintfp(Prepare("%h[bold]foo")).HasColor() {
+		t.Errorf("Expected Format expanded with a colored Format to have color")
+	}
+	if Prepare("%s").Eprintfp("foo").HasColor() {
+		t.Errorf("Expected Format expanded with a string to have no color")
+	}
+}
+
+func TestOpenClose(t *testing.T) {
+	t.Parallel()
+	f := Prepare("%h[fgRed+bold]")
+	if r := f.Open(true); r != Highlight("%h[fgRed+bold]") {
+		t.Errorf("Expected %q but result was %q", Highlight("%h[fgRed+bold]"), r)
+	}
+	exp := reset()
+	if tiErr != nil {
+		exp = ""
+	}
+	if r := f.Close(true); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r := f.Open(false) + f.Close(false); r != "" {
+		t.Errorf("Expected %q but result was %q", "", r)
+	}
+	for _, s := range []string{"%h[fgRed]foo", "foo", "%h[fgRed]%s", "%h[fgRed]%r"} {
+		f := Prepare(s)
+		if s == "%h[fgRed]%r" {
+			if r := f.Close(true); r != "" {
+				t.Errorf("Expected %q from %q but result was %q", "", s, r)
+			}
+			continue
+		}
+		if r := f.Open(true) + f.Close(true); r != "" {
+			t.Errorf("Expected %q from %q but result was %q", "", s, r)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	t.Parallel()
+	formats := map[string]bool{
+		"%h[fgRed]foo":      true,
+		"%h[fgRed]foo%r":    false,
+		"foo":               false,
+		"%h[bold]%r%h[dim]": true,
+	}
+	for k, v := range formats {
+		if r := Prepare(k).Active(); r != v {
+			t.Errorf("Expected %v from %q but result was %v", v, k, r)
+		}
+	}
+}
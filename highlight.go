@@ -2,8 +2,10 @@ package color
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/nhooyr/terminfo"
@@ -15,6 +17,7 @@ const (
 	errMissing = "%%!h(MISSING)" // no attributes in the verb
 	errShort   = "%%!h(SHORT)"   // string ended before the verb
 	errBadAttr = "%%!h(BADATTR)" // unknown attribute in the verb
+	errNoBlock = "%%!}(NOBLOCK)" // end of a block that was not opened
 )
 
 // highlighter holds the state of the scanner.
@@ -24,6 +27,40 @@ type highlighter struct {
 	buf   *bytes.Buffer // where result is built
 	color bool          // color or strip the highlight verbs
 	fg    bool          // foreground or background color attribute
+	base  string        // written after every reset
+	err   string        // error encountered in s
+
+	active bool // attributes are active in the output
+
+	open, close byte // delimiters of the attributes in the verb
+	flags       int  // Printer flags that change the output
+
+	info      *FormatInfo // where Analyze records the verbs, nil otherwise
+	attrStart int         // position of the current attribute in s
+
+	verbPos    int  // position of the current verb in s
+	verbStart  int  // length of buf at the start of the current verb
+	verbActive bool // active at the start of the current verb
+
+	st        *Style // where SprintfState tracks the style, nil otherwise
+	verbPrev  Style  // style at the start of the current verb
+	untracked bool   // current verb has attributes that st cannot represent
+	controls  string // control sequences of the current verb
+	link      bool   // a hyperlink is open in the output
+	linkURL   string // hyperlink of the current verb, opened once the verb is complete
+	linkAt    int    // length of buf where the hyperlink of the current verb goes
+	depth     int    // number of registered styles being tracked around this highlighter
+
+	blocks    []block // blocks opened by %{ that are not closed yet
+	blockVerb bool    // current verb opens a block
+	resetPos  int     // length of buf after the last reset and its base
+	verbReset int     // resetPos at the start of the current verb
+}
+
+// block is the state of the output at the start of a block, which its end restores.
+type block struct {
+	sgr string // SGR sequences active at the start of the block unless hl.st is tracked
+	st  Style  // style at the start of the block if hl.st is tracked
 }
 
 // highlighterPool allows the reuse of highlighters to avoid allocations.
@@ -40,10 +77,54 @@ var highlighterPool = sync.Pool{
 // Global terminfo struct.
 var ti, tiErr = terminfo.LoadEnv()
 
+var (
+	delimsMu   sync.RWMutex
+	openDelim  byte = '['
+	closeDelim byte = ']'
+)
+
+// SetVerbDelimiters sets the delimiters of the attributes in the highlight verb, which
+// are '[' and ']' by default. For example, after SetVerbDelimiters('<', '>') the verb
+// is written as %h<fgRed+bold>. The delimiters must be distinct printable ASCII
+// characters that cannot appear in attributes, so they must not be letters, digits or
+// any of "%+#=;(),-". They must not be '{' or '}' either, which delimit blocks.
+// The delimiters are used by everything that processes highlight verbs. Formats and
+// styles that were prepared or registered before the call are not affected.
+// It is safe to call SetVerbDelimiters concurrently.
+func SetVerbDelimiters(open, close byte) error {
+	for _, ch := range []byte{open, close} {
+		if ch <= ' ' || ch >= 0x7f || strings.IndexByte("%+#=;(),-{}", ch) != -1 ||
+			(ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') {
+			return fmt.Errorf("color: invalid verb delimiter %q", ch)
+		}
+	}
+	if open == close {
+		return fmt.Errorf("color: verb delimiters must be distinct, both are %q", open)
+	}
+	delimsMu.Lock()
+	defer delimsMu.Unlock()
+	openDelim, closeDelim = open, close
+	return nil
+}
+
+// delims returns the delimiters of the attributes in the highlight verb.
+func delims() (open, close byte) {
+	delimsMu.RLock()
+	defer delimsMu.RUnlock()
+	return openDelim, closeDelim
+}
+
+// verb returns the highlight verb with the attributes in spec.
+func verb(spec string) string {
+	open, close := delims()
+	return "%h" + string(open) + spec + string(close)
+}
+
 // newHighlighter returns a new initialized highlighter from the pool.
 func newHighlighter(s string, color bool) *highlighter {
 	hl := highlighterPool.Get().(*highlighter)
 	hl.s = s
+	hl.open, hl.close = delims()
 	if tiErr != nil {
 		hl.color = false
 	} else {
@@ -56,6 +137,19 @@ func newHighlighter(s string, color bool) *highlighter {
 func (hl *highlighter) free() {
 	hl.buf.Reset()
 	hl.pos = 0
+	hl.base = ""
+	hl.err = ""
+	hl.active = false
+	hl.flags = 0
+	hl.info = nil
+	hl.st = nil
+	hl.controls = ""
+	hl.link = false
+	hl.linkURL = ""
+	hl.depth = 0
+	hl.blocks = hl.blocks[:0]
+	hl.blockVerb = false
+	hl.resetPos = 0
 	highlighterPool.Put(hl)
 }
 
@@ -76,20 +170,70 @@ func Strip(s string) string {
 // determines whether the highlight verbs will be replaced with their appropriate control
 // sequences or instead stripped.
 func Run(s string, color bool) string {
+	out, _ := process(s, color, "", 0)
+	return out
+}
+
+// Runb is the same as Run but it appends the output to dst and returns the extended
+// buffer, like strconv.AppendInt. It avoids allocating a string for the output.
+func Runb(dst []byte, s string, color bool) []byte {
+	if !HasVerbs(s) {
+		return append(dst, s...)
+	}
 	hl := newHighlighter(s, color)
 	defer hl.free()
-	return hl.run()
+	hl.exec()
+	return append(dst, hl.buf.Bytes()...)
+}
+
+// process is the same as Run but it also writes base after every reset so that resets
+// revert to base instead of the terminal default and it applies the Printer flags.
+// It also returns whether any attributes are still active at the end of the output.
+func process(s string, color bool, base string, flags int) (out string, active bool) {
+	if !HasVerbs(s) {
+		return s, false
+	}
+	hl := newHighlighter(s, color)
+	defer hl.free()
+	hl.base = base
+	hl.flags = flags
+	hl.exec()
+	if hl.link && flags&AutoResetEnd != 0 && hl.color {
+		hl.buf.WriteString(linkEnd)
+	}
+	return hl.buf.String(), hl.active
+}
+
+// HasVerbs returns true if s contains any %h, %r, %{ or %} verbs, not counting escaped ones
+// such as %%h. It is much cheaper than processing s and everything that processes
+// highlight verbs returns a string without them unchanged, so it can be used to skip
+// the processing of plain strings.
+func HasVerbs(s string) bool {
+	for i := 0; i < len(s)-1; i++ {
+		if s[i] == '%' {
+			i++
+			if s[i] == 'h' || s[i] == 'r' || s[i] == '{' || s[i] == '}' {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // stateFn represents the state of the scanner as a function that returns the next state.
 type stateFn func(*highlighter) stateFn
 
-// run runs the state machine for the highlighter.
+// run runs the state machine for the highlighter and returns the output.
 func (hl *highlighter) run() string {
+	hl.exec()
+	return hl.buf.String()
+}
+
+// exec runs the state machine for the highlighter.
+func (hl *highlighter) exec() {
 	for state := scanText; state != nil; {
 		state = state(hl)
 	}
-	return hl.buf.String()
 }
 
 // get returns the current character.
@@ -114,6 +258,62 @@ func (hl *highlighter) writeFrom(ppos int) {
 
 func (hl *highlighter) writeAttr(a string) {
 	hl.buf.WriteString(a)
+	hl.active = true
+}
+
+// writeError writes the error e to the buffer and records it.
+func (hl *highlighter) writeError(e string) {
+	hl.err = e
+	if hl.info != nil {
+		hl.info.errPos = hl.pos
+		if e == errBadAttr {
+			hl.info.errPos = hl.attrStart
+		}
+	}
+	hl.buf.WriteString(e)
+}
+
+// badAttr handles an unknown attribute in the current verb. Unless the PassthroughUnknown
+// flag is set, it writes the error and stops the scanner.
+func (hl *highlighter) badAttr() stateFn {
+	if hl.flags&PassthroughUnknown == 0 {
+		hl.writeError(errBadAttr)
+		return nil
+	}
+	// Replace the output of the verb with the verb itself, escaped for fmt.
+	hl.buf.Truncate(hl.verbStart)
+	hl.active = hl.verbActive
+	if hl.st != nil {
+		*hl.st = hl.verbPrev
+	}
+	// A block is still opened by the verb, it ends as usual.
+	hl.resetPos = hl.verbReset
+	hl.linkURL = ""
+	end := len(hl.s)
+	if i := strings.IndexByte(hl.s[hl.pos:], hl.closeDelim()); i != -1 {
+		end = hl.pos + i + 1
+	}
+	hl.buf.WriteByte('%')
+	hl.buf.WriteString(hl.s[hl.verbPos:end])
+	hl.pos = end
+	hl.blockVerb = false
+	return scanText
+}
+
+// writeReset writes the reset sequence followed by the base.
+func (hl *highlighter) writeReset() {
+	hl.buf.WriteString(ti.Strings[caps.ExitAttributeMode])
+	hl.buf.WriteString(hl.base)
+	hl.active = false
+	hl.resetPos = hl.buf.Len()
+}
+
+// closeDelim returns the closing delimiter of the current verb.
+func (hl *highlighter) closeDelim() byte {
+	if hl.blockVerb {
+		return '}'
+	}
+	return hl.close
 }
 
 // scanAttribute returns the string from the current character to
@@ -126,7 +326,7 @@ func (hl *highlighter) scanAttribute() (string, error) {
 		if err != nil {
 			return "", err
 		}
-		if ch == '+' || ch == ']' {
+		if ch == '+' || ch == hl.closeDelim() {
 			break
 		}
 		hl.pos++
@@ -163,41 +363,133 @@ func scanVerb(hl *highlighter) stateFn {
 	hl.pos++
 	switch ch {
 	case 'r':
+		if hl.info != nil {
+			hl.info.Resets++
+			hl.info.Unbalanced = false
+		}
+		if hl.st != nil {
+			*hl.st = Style{}
+		}
 		if hl.color {
-			hl.writeAttr(ti.Strings[caps.ExitAttributeMode])
+			hl.writeReset()
 		}
 		return scanText
 	case 'h':
-		// Ensure next character is '['.
+		hl.startVerb()
+		// Ensure next character is the opening delimiter.
 		ch, err = hl.get()
 		if err != nil {
-			hl.buf.WriteString(errShort)
+			hl.writeError(errShort)
 			return nil
 		}
-		if ch != '[' {
-			hl.buf.WriteString(errInvalid)
+		if ch != hl.open {
+			hl.writeError(errInvalid)
 			return nil
 		}
-		// Ensure next character is not ']'.
 		hl.pos++
-		ch, err = hl.get()
-		if err != nil {
-			hl.buf.WriteString(errShort)
-			return nil
-		}
-		if ch == ']' {
-			hl.buf.WriteString(errMissing)
-			return nil
-		}
-		return startAttribute
+		return scanSpec
+	case '{':
+		hl.startVerb()
+		hl.openBlock()
+		return scanSpec
+	case '}':
+		return closeBlock
 	}
 	// Include the verb.
 	hl.writePrev(2)
 	return scanText
 }
 
+// startVerb records the state at the start of the current verb, whose "%h" or "%{"
+// was just read.
+func (hl *highlighter) startVerb() {
+	if hl.info != nil {
+		hl.info.Highlights++
+	}
+	hl.verbPos = hl.pos - 2
+	hl.verbStart = hl.buf.Len()
+	hl.verbActive = hl.active
+	hl.verbReset = hl.resetPos
+	if hl.st != nil {
+		hl.verbPrev = *hl.st
+		hl.untracked = false
+		hl.controls = ""
+	}
+}
+
+// scanSpec ensures that the current verb, after its opening delimiter, has attributes
+// and passes control to startAttribute.
+func scanSpec(hl *highlighter) stateFn {
+	ch, err := hl.get()
+	if err != nil {
+		hl.writeError(errShort)
+		return nil
+	}
+	if ch == hl.closeDelim() {
+		hl.writeError(errMissing)
+		return nil
+	}
+	return startAttribute
+}
+
+// openBlock opens a block with the current verb, recording the state that its end restores.
+func (hl *highlighter) openBlock() {
+	hl.blockVerb = true
+	var b block
+	if hl.st != nil {
+		// The end of the block writes the difference from the style instead.
+		b.st = *hl.st
+	} else if hl.color {
+		// The sequences written since the last reset are the active ones.
+		var active sgrState
+		out := hl.buf.String()[hl.resetPos:]
+		for i := 0; i < len(out); i++ {
+			if out[i] != '\x1b' {
+				continue
+			}
+			if n := escapeLen(out[i:]); n > 0 {
+				active.track(out[i : i+n])
+				i += n - 1
+			}
+		}
+		b.sgr = active.sgr()
+	}
+	hl.blocks = append(hl.blocks, b)
+}
+
+// closeBlock ends the innermost open block by restoring the state at its start,
+// which is only a reset if nothing was active.
+func closeBlock(hl *highlighter) stateFn {
+	if len(hl.blocks) == 0 {
+		hl.writeError(errNoBlock)
+		return nil
+	}
+	b := hl.blocks[len(hl.blocks)-1]
+	hl.blocks = hl.blocks[:len(hl.blocks)-1]
+	if hl.info != nil {
+		hl.info.Resets++
+		hl.info.Unbalanced = false
+	}
+	if hl.st != nil {
+		if hl.color {
+			hl.buf.WriteString(b.st.Diff(*hl.st))
+			hl.active = !b.st.isDefault()
+		}
+		*hl.st = b.st
+		return scanText
+	}
+	if hl.color {
+		hl.writeReset()
+		if b.sgr != "" {
+			hl.writeAttr(b.sgr)
+		}
+	}
+	return scanText
+}
+
 // startAttribute checks the type of the attribute and passes control appropriately.
 func startAttribute(hl *highlighter) stateFn {
+	hl.attrStart = hl.pos
 	// No need to check error because the character was already read.
 	switch ch, _ := hl.get(); ch {
 	case 'f':
@@ -209,11 +501,11 @@ func startAttribute(hl *highlighter) stateFn {
 	}
 	// Attribute starts with 'f' or 'b' so it could be a color attribute.
 	// Rest of the code confirms if it is a color attribute, and if so,
-	// whether it is a named or a 256 color attribute.
+	// whether it is a named, a 256 or a true color attribute.
 	hl.pos++
 	ch, err := hl.get()
 	if err != nil {
-		hl.buf.WriteString(errShort)
+		hl.writeError(errShort)
 		return nil
 	}
 	if ch != 'g' {
@@ -221,16 +513,19 @@ func startAttribute(hl *highlighter) stateFn {
 		hl.pos--
 		return scanMode
 	}
-	// Now check if it is a named or 256 color attribute.
+	// Now check if it is a named, 256 or true color attribute.
 	hl.pos++
 	ch, err = hl.get()
 	if err != nil {
-		hl.buf.WriteString(errShort)
+		hl.writeError(errShort)
 		return nil
 	}
 	if ch >= '0' && ch <= '9' {
 		return scanColor256
 	}
+	if ch == '#' {
+		return scanColorRGB
+	}
 	return scanColor
 }
 
@@ -248,17 +543,276 @@ var modes = map[string]int16{
 func scanMode(hl *highlighter) stateFn {
 	a, err := hl.scanAttribute()
 	if err != nil {
-		hl.buf.WriteString(errShort)
+		hl.writeError(errShort)
 		return nil
 	}
 	if n, ok := modes[a]; ok {
+		if hl.st != nil {
+			if n == caps.ExitAttributeMode {
+				*hl.st = Style{}
+			} else if !isSuppressed(a) {
+				hl.st.Attrs |= modeAttrs[a]
+			}
+		}
 		if hl.color {
-			hl.writeAttr(ti.Strings[n])
+			if n == caps.ExitAttributeMode {
+				hl.writeReset()
+			} else if !isSuppressed(a) {
+				hl.writeAttr(ti.Strings[n])
+			}
 		}
 		return endAttribute
 	}
-	hl.buf.WriteString(errBadAttr)
-	return nil
+	if c, ok := controls[a]; ok {
+		hl.writeControl(c)
+		return endAttribute
+	}
+	if strings.HasPrefix(a, "-") {
+		return modeOff(hl, a[1:])
+	}
+	if strings.HasPrefix(a, "link=") {
+		return link(hl, a[len("link="):])
+	}
+	if a == "linkend" {
+		if hl.link {
+			hl.link = false
+			hl.writeControl(linkEnd)
+		}
+		return endAttribute
+	}
+	if strings.HasPrefix(a, "raw=") {
+		hl.untracked = true
+		return raw(hl, a[len("raw="):])
+	}
+	if strings.HasPrefix(a, "underline=") {
+		hl.untracked = true
+		if hl.st != nil && !isSuppressed("underline") {
+			hl.st.Attrs |= Underline
+		}
+		return underlineStyle(hl, a[len("underline="):])
+	}
+	if strings.HasPrefix(a, "ulcolor") {
+		hl.untracked = true
+		return underlineColor(hl, a[len("ulcolor"):])
+	}
+	if st, ok := lookupStyle(a); ok {
+		if hl.st != nil {
+			hl.trackStyle(st.spec)
+		}
+		if hl.color {
+			hl.writeAttr(st.colored)
+		}
+		return endAttribute
+	}
+	return hl.badAttr()
+}
+
+// modeOff turns off the mode a with its SGR code, e.g. 22 for bold.
+// Turning off bold or dim turns off both, as they share the code.
+func modeOff(hl *highlighter, a string) stateFn {
+	attr, ok := modeAttrs[a]
+	if !ok {
+		return hl.badAttr()
+	}
+	off := 0
+	for i, c := range attrCodes {
+		if attr == Attr(1<<uint(i)) {
+			off = c.off
+		}
+	}
+	if hl.st != nil {
+		if off == 22 {
+			attr = Bold | Dim
+		}
+		hl.st.Attrs &^= attr
+	}
+	if hl.color {
+		hl.writeAttr("\x1b[" + strconv.Itoa(off) + "m")
+	}
+	return endAttribute
+}
+
+// controls maps control names to their sequences.
+var controls = map[string]string{
+	"bell":      "\a",
+	"clear":     "\x1b[2J\x1b[H",
+	"clearline": "\x1b[2K\r",
+}
+
+// writeControl writes the control sequence c. Control sequences do not change
+// the attributes, so they need no reset and resets do not affect them.
+func (hl *highlighter) writeControl(c string) {
+	if hl.st != nil {
+		hl.controls += c
+	}
+	if hl.color {
+		hl.buf.WriteString(c)
+	}
+}
+
+// linkEnd is the OSC 8 sequence that closes a hyperlink.
+const linkEnd = "\x1b]8;;\x1b\\"
+
+// link opens a hyperlink to url with an OSC 8 sequence. Terminals replace
+// a hyperlink that is already open. It is only written once the rest of the verb
+// is known to be valid, so that an error does not leave a hyperlink open.
+func link(hl *highlighter, url string) stateFn {
+	if url == "" {
+		return hl.badAttr()
+	}
+	for i := 0; i < len(url); i++ {
+		if url[i] < ' ' || url[i] == 0x7f {
+			return hl.badAttr()
+		}
+	}
+	hl.linkURL = url
+	hl.linkAt = hl.buf.Len()
+	return endAttribute
+}
+
+// openLink writes the hyperlink of the current verb where its attribute was.
+func (hl *highlighter) openLink() {
+	// The output is a format for fmt.
+	c := "\x1b]8;;" + strings.Replace(hl.linkURL, "%", "%%", -1) + "\x1b\\"
+	hl.linkURL = ""
+	hl.link = true
+	if hl.st != nil {
+		hl.controls += c
+	}
+	if !hl.color {
+		return
+	}
+	rest := string(hl.buf.Bytes()[hl.linkAt:])
+	hl.buf.Truncate(hl.linkAt)
+	hl.buf.WriteString(c)
+	hl.buf.WriteString(rest)
+}
+
+// modeAttrs maps mode names to their attributes in a Style.
+var modeAttrs = map[string]Attr{
+	"bold":      Bold,
+	"underline": Underline,
+	"reverse":   Reverse,
+	"blink":     Blink,
+	"dim":       Dim,
+}
+
+// maxStyleDepth limits how deep trackStyle follows registered styles that refer to
+// other registered styles, which could refer to each other after being registered again.
+const maxStyleDepth = 8
+
+// trackStyle applies the attributes in spec, the spec of a registered style, to hl.st.
+func (hl *highlighter) trackStyle(spec string) {
+	if hl.depth >= maxStyleDepth {
+		hl.untracked = true
+		return
+	}
+	sub := newHighlighter(verb(spec), false)
+	sub.st = hl.st
+	sub.depth = hl.depth + 1
+	sub.run()
+	if sub.untracked {
+		hl.untracked = true
+	}
+	hl.controls += sub.controls
+	sub.free()
+}
+
+// setColor sets the foreground or background color of hl.st to c.
+func (hl *highlighter) setColor(c Color) {
+	if hl.st == nil {
+		return
+	}
+	if hl.fg {
+		hl.st.Fg = c
+	} else {
+		hl.st.Bg = c
+	}
+}
+
+// raw writes the parameters of a raw attribute as a SGR sequence without
+// interpreting them.
+func raw(hl *highlighter, params string) stateFn {
+	if params == "" {
+		return hl.badAttr()
+	}
+	for i := 0; i < len(params); i++ {
+		ch := params[i]
+		if ch != ';' && (ch < '0' || ch > '9') && (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') {
+			return hl.badAttr()
+		}
+	}
+	if hl.color {
+		hl.writeAttr("\x1b[" + params + "m")
+	}
+	return endAttribute
+}
+
+// underlineStyles maps underline style names to their SGR 4 subparameters.
+var underlineStyles = map[string]int{
+	"single": 1,
+	"double": 2,
+	"curly":  3,
+	"dotted": 4,
+	"dashed": 5,
+}
+
+// underlineStyle writes a styled underline. It writes a plain underline first so that
+// terminals which ignore the subparameter still underline.
+func underlineStyle(hl *highlighter, style string) stateFn {
+	n, ok := underlineStyles[style]
+	if !ok {
+		return hl.badAttr()
+	}
+	if hl.color && !isSuppressed("underline") {
+		a := ti.Strings[caps.EnterUnderlineMode]
+		if n != 1 {
+			a += "\x1b[4:" + strconv.Itoa(n) + "m"
+		}
+		hl.writeAttr(a)
+	}
+	return endAttribute
+}
+
+// underlineColor writes the SGR 58 sequence that sets the underline color to c, which is
+// a color name, a number from 0-255, #rrggbb or Default. There is no terminfo capability
+// for it.
+func underlineColor(hl *highlighter, c string) stateFn {
+	var a string
+	if n, ok := colors[c]; ok {
+		a = hl.sgrPalette(58, n)
+	} else if c == "Default" {
+		a = "\x1b[59m"
+	} else if len(c) == len("#rrggbb") && c[0] == '#' {
+		v, err := strconv.ParseUint(c[1:], 16, 32)
+		if err != nil {
+			return hl.badAttr()
+		}
+		rgb := RGB{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+		if hl.flags&Force16 != 0 {
+			a = hl.sgrPalette(58, nearest16(rgb))
+		} else if hl.flags&Force256 != 0 {
+			a = hl.sgrPalette(58, int(NearestPaletteIndex(rgb)))
+		} else {
+			a = hl.sgrRGB(58, rgb.R, rgb.G, rgb.B)
+		}
+	} else {
+		if c == "" || c[0] < '0' || c[0] > '9' {
+			return hl.badAttr()
+		}
+		n, err := strconv.Atoi(c)
+		if err != nil || n > 255 {
+			return hl.badAttr()
+		}
+		if hl.flags&Force16 != 0 && n >= 16 {
+			n = nearest16(RGBForIndex(uint8(n)))
+		}
+		a = hl.sgrPalette(58, n)
+	}
+	if hl.color {
+		hl.writeAttr(a)
+	}
+	return endAttribute
 }
 
 // colors maps color names to their integer values.
@@ -285,10 +839,11 @@ var colors = map[string]int{
 func scanColor(hl *highlighter) stateFn {
 	a, err := hl.scanAttribute()
 	if err != nil {
-		hl.buf.WriteString(errShort)
+		hl.writeError(errShort)
 		return nil
 	}
 	if c, ok := colors[a]; ok {
+		hl.setColor(PaletteColor(uint8(c)))
 		if hl.color {
 			if hl.fg {
 				hl.writeAttr(ti.Color(c, -1))
@@ -298,44 +853,302 @@ func scanColor(hl *highlighter) stateFn {
 		}
 		return endAttribute
 	}
-	hl.buf.WriteString(errBadAttr)
-	return nil
+	if a == "Default" {
+		hl.setColor(DefaultColor)
+		if hl.color {
+			hl.writeDefault()
+		}
+		return endAttribute
+	}
+	if !hl.fg && strings.HasPrefix(a, "Fill") {
+		return fill(hl, a[len("Fill"):])
+	}
+	if strings.HasPrefix(a, "ansi") {
+		return ansi(hl, a[len("ansi"):])
+	}
+	if strings.HasPrefix(a, "gray") {
+		return gray(hl, a[len("gray"):])
+	}
+	if strings.HasPrefix(a, "cube") {
+		return cube(hl, a[len("cube"):])
+	}
+	return hl.badAttr()
+}
+
+// ansi writes the basic SGR color code for the color number c from 0-15.
+func ansi(hl *highlighter, c string) stateFn {
+	if c == "" || c[0] < '0' || c[0] > '9' {
+		return hl.badAttr()
+	}
+	n, err := strconv.Atoi(c)
+	if err != nil || n > 15 {
+		return hl.badAttr()
+	}
+	hl.setColor(PaletteColor(uint8(n)))
+	if hl.color {
+		hl.writeAttr(colorANSI(hl.fg, n))
+	}
+	return endAttribute
+}
+
+// colorANSI returns the SGR sequence that sets the foreground or background to the
+// color n from 0-15 with the 30-37 and 90-97 codes or the 40-47 and 100-107 codes.
+// Terminfo maps these colors to the 256 color sequences on some terminals.
+func colorANSI(fg bool, n int) string {
+	code := 30 + n
+	if n >= 8 {
+		code = 90 + n - 8
+	}
+	if !fg {
+		code += 10
+	}
+	return "\x1b[" + strconv.Itoa(code) + "m"
+}
+
+// fill writes the named or 256 background color c followed by the sequence that erases
+// to the end of the line, which fills the rest of the line with the background color.
+func fill(hl *highlighter, c string) stateFn {
+	n, ok := colors[c]
+	if !ok {
+		if c == "" || c[0] < '0' || c[0] > '9' {
+			return hl.badAttr()
+		}
+		var err error
+		n, err = strconv.Atoi(c)
+		if err != nil || n > 255 {
+			return hl.badAttr()
+		}
+		if hl.flags&Force16 != 0 && n >= 16 {
+			n = nearest16(RGBForIndex(uint8(n)))
+		}
+	}
+	hl.setColor(PaletteColor(uint8(n)))
+	// The erase cannot be represented.
+	hl.untracked = true
+	if hl.color {
+		if hl.flags&ColonSubparams != 0 && n >= 16 {
+			hl.writeAttr(hl.sgrPalette(48, n) + eraseLine)
+		} else {
+			hl.writeAttr(ti.Color(-1, n) + eraseLine)
+		}
+	}
+	return endAttribute
+}
+
+// eraseLine is the sequence that erases from the cursor to the end of the line.
+const eraseLine = "\x1b[K"
+
+// bothDefault is the SGR sequence that sets both colors to the terminal default.
+const bothDefault = "\x1b[39;49m"
+
+// writeDefault writes the sequence that sets the foreground or background color to the
+// terminal default. If the previous attribute of the verb set the other color to the
+// default, both are set with a single sequence instead.
+func (hl *highlighter) writeDefault() {
+	other := colorDefault(!hl.fg)
+	if b := hl.buf.Bytes(); len(b)-len(other) >= hl.verbStart && string(b[len(b)-len(other):]) == other {
+		hl.buf.Truncate(len(b) - len(other))
+		hl.writeAttr(bothDefault)
+		return
+	}
+	hl.writeAttr(colorDefault(hl.fg))
+}
+
+// colorDefault returns the SGR sequence that sets the foreground or background
+// to the terminal default. The orig_pair capability would set both.
+func colorDefault(fg bool) string {
+	if fg {
+		return "\x1b[39m"
+	}
+	return "\x1b[49m"
 }
 
 // scanColor256 scans a 256 color attribute.
 func scanColor256(hl *highlighter) stateFn {
 	a, err := hl.scanAttribute()
 	if err != nil {
-		hl.buf.WriteString(errShort)
+		hl.writeError(errShort)
 		return nil
 	}
 	t, err := strconv.Atoi(a)
-	if err != nil {
-		hl.buf.WriteString(errBadAttr)
-		return nil
+	if err != nil || t > 255 {
+		// An index out of range would produce a malformed sequence.
+		return hl.badAttr()
 	}
+	hl.writeColor256(t)
+	return endAttribute
+}
+
+// writeColor256 writes the 256 color t, which must be from 0-255, as the foreground or background color.
+func (hl *highlighter) writeColor256(t int) {
+	if hl.flags&Force16 != 0 && t >= 16 {
+		t = nearest16(RGBForIndex(uint8(t)))
+	}
+	hl.setColor(PaletteColor(uint8(t)))
 	if hl.color {
-		if hl.fg {
+		if hl.flags&ColonSubparams != 0 && t >= 16 {
+			hl.writeAttr(hl.sgrPalette(colorCode(hl.fg), t))
+		} else if hl.fg {
 			hl.writeAttr(ti.Color(t, -1))
 		} else {
 			hl.writeAttr(ti.Color(-1, t))
 		}
 	}
+}
+
+// gray writes the color at step c from 0-23 of the grayscale ramp of the 256 colors.
+func gray(hl *highlighter, c string) stateFn {
+	if c == "" || c[0] < '0' || c[0] > '9' {
+		return hl.badAttr()
+	}
+	n, err := strconv.Atoi(c)
+	if err != nil || n > 23 {
+		return hl.badAttr()
+	}
+	hl.writeColor256(int(Gray(uint8(n))))
+	return endAttribute
+}
+
+// cube writes the color of the 6x6x6 color cube of the 256 colors at c, "(r,g,b)"
+// where each component is from 0-5.
+func cube(hl *highlighter, c string) stateFn {
+	if len(c) != len("(r,g,b)") || c[0] != '(' || c[2] != ',' || c[4] != ',' || c[6] != ')' {
+		return hl.badAttr()
+	}
+	var rgb [3]uint8
+	for i := range rgb {
+		ch := c[1+2*i]
+		if ch < '0' || ch > '5' {
+			return hl.badAttr()
+		}
+		rgb[i] = ch - '0'
+	}
+	hl.writeColor256(int(CubeIndex(rgb[0], rgb[1], rgb[2])))
+	return endAttribute
+}
+
+// CubeIndex returns the index of the color with the components r, g and b from 0-5 in
+// the 6x6x6 color cube of the 256 color palette, 16 + 36*r + 6*g + b. Components above
+// 5 are treated as 5.
+func CubeIndex(r, g, b uint8) uint8 {
+	if r > 5 {
+		r = 5
+	}
+	if g > 5 {
+		g = 5
+	}
+	if b > 5 {
+		b = 5
+	}
+	return 16 + 36*r + 6*g + b
+}
+
+// Gray returns the index of step n from 0-23 of the grayscale ramp in the 256 color
+// palette, from near black to near white. Steps above 23 are treated as 23.
+func Gray(n uint8) uint8 {
+	if n > 23 {
+		n = 23
+	}
+	return 232 + n
+}
+
+// scanColorRGB scans a true color attribute.
+func scanColorRGB(hl *highlighter) stateFn {
+	a, err := hl.scanAttribute()
+	if err != nil {
+		hl.writeError(errShort)
+		return nil
+	}
+	if len(a) != len("#rrggbb") {
+		return hl.badAttr()
+	}
+	v, err := strconv.ParseUint(a[1:], 16, 32)
+	if err != nil {
+		return hl.badAttr()
+	}
+	c := RGB{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+	if hl.flags&(Force16|Force256) == Force256 {
+		hl.writeColor256(int(NearestPaletteIndex(c)))
+		return endAttribute
+	}
+	if hl.flags&Force16 == 0 {
+		hl.setColor(TrueColor(c.R, c.G, c.B))
+	} else {
+		hl.setColor(PaletteColor(uint8(nearest16(c))))
+	}
+	if hl.color {
+		if hl.flags&Force16 == 0 {
+			hl.writeAttr(hl.sgrRGB(colorCode(hl.fg), c.R, c.G, c.B))
+		} else if hl.fg {
+			hl.writeAttr(ti.Color(nearest16(c), -1))
+		} else {
+			hl.writeAttr(ti.Color(-1, nearest16(c)))
+		}
+	}
 	return endAttribute
 }
 
+// colorCode returns the SGR code of an extended foreground or background color.
+func colorCode(fg bool) int {
+	if fg {
+		return 38
+	}
+	return 48
+}
+
+// sgrPalette returns the SGR sequence of the extended color code, 38, 48 or 58, with
+// the 256 color n. The ColonSubparams flag selects the colon form.
+func (hl *highlighter) sgrPalette(code, n int) string {
+	sep := ";"
+	if hl.flags&ColonSubparams != 0 {
+		sep = ":"
+	}
+	return "\x1b[" + strconv.Itoa(code) + sep + "5" + sep + strconv.Itoa(n) + "m"
+}
+
+// sgrRGB returns the SGR sequence of the extended color code, 38, 48 or 58, with the
+// true color r, g, b. The colon form of the ColonSubparams flag has an empty color
+// space identifier before the components, as specified by ITU-T T.416.
+func (hl *highlighter) sgrRGB(code int, r, g, b uint8) string {
+	if hl.flags&ColonSubparams != 0 {
+		return "\x1b[" + strconv.Itoa(code) + ":2::" + strconv.Itoa(int(r)) + ":" + strconv.Itoa(int(g)) + ":" + strconv.Itoa(int(b)) + "m"
+	}
+	return "\x1b[" + strconv.Itoa(code) + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)) + "m"
+}
+
 // endAttribute handles the end of attributes. If there is another attribute, control is
 // thrown to scanHighlight, but if the verb has ended, control is thrown to scanText.
 func endAttribute(hl *highlighter) stateFn {
+	if hl.info != nil {
+		hl.info.addAttribute(hl.s[hl.attrStart:hl.pos])
+	}
 	ch, _ := hl.get()
 	hl.pos++
-	if ch == ']' {
+	if ch == hl.closeDelim() {
+		if hl.info != nil {
+			// Between "%h" and its delimiters or "%{" and '}'.
+			start := hl.verbPos + 3
+			if hl.blockVerb {
+				start--
+			}
+			hl.info.Specs = append(hl.info.Specs, hl.s[start:hl.pos-1])
+		}
+		hl.blockVerb = false
+		if hl.linkURL != "" {
+			hl.openLink()
+		}
+		if hl.st != nil && hl.color && !hl.untracked {
+			// Only write what the verb changes.
+			hl.buf.Truncate(hl.verbStart)
+			hl.buf.WriteString(hl.st.Diff(hl.verbPrev))
+			hl.buf.WriteString(hl.controls)
+		}
 		return scanText
 	}
 	// Must read the next character here because scanHighlight assumes that
 	// the character was already read. See scanVerb.
 	if _, err := hl.get(); err != nil {
-		hl.buf.WriteString(errShort)
+		hl.writeError(errShort)
 		return nil
 	}
 	return startAttribute
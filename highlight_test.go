@@ -1,6 +1,7 @@
 package color
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -64,6 +65,22 @@ func TestColors256(t *testing.T) {
 	}
 }
 
+func TestColors256Range(t *testing.T) {
+	t.Parallel()
+	if r, exp := Highlight("%h[fg255]hi"), expF(ti.Color(255, -1)+"%s", "hi"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	for _, s := range []string{"%h[fg256]hi", "%h[bg300]hi", "%h[bgFill256]hi", "%h[fg99999999999999999999]hi"} {
+		if r := Highlight(s); r != errBadAttr {
+			t.Errorf("Expected %q from %q but result was %q", errBadAttr, s, r)
+		}
+	}
+	_, err := Analyze("foo %h[bold+fg256]")
+	if se, ok := err.(*SyntaxError); !ok || se.Offset != 12 || se.Err != errBadAttr[1:] {
+		t.Errorf("Expected a BADATTR SyntaxError at offset 12 but result was %v", err)
+	}
+}
+
 var combinations = map[string]string{
 	"%h[fgRed+bgBlue+bold+underline+fg23+bg235]hi":         expF(ti.Color(caps.Red, caps.Blue)+ti.Strings[caps.EnterBoldMode]+ti.Strings[caps.EnterUnderlineMode]+ti.Color(23, 235)+"%s", "hi"),
 	"%h[bgBlue+fgYellow+fgGreen+fg34+blink+dim+reverse]hi": expF(ti.Color(-1, caps.Blue)+ti.Color(caps.Yellow, -1)+ti.Color(caps.Green, -1)+ti.Color(34, -1)+ti.Strings[caps.EnterBlinkMode]+ti.Strings[caps.EnterDimMode]+ti.Strings[caps.EnterReverseMode]+"%s", "hi"),
@@ -78,6 +95,28 @@ func TestCombinations(t *testing.T) {
 	}
 }
 
+var raws = map[string]string{
+	"%h[raw=1;2;3]hi":          exp("\x1b[1;2;3m") + "hi",
+	"%h[bold+raw=38;5;2]hi":    exp(ti.Strings[caps.EnterBoldMode]+"\x1b[38;5;2m") + "hi",
+	"%h[raw=5A+fgRed]hi":       exp("\x1b[5Am"+ti.Color(caps.Red, -1)) + "hi",
+	"%h[raw=]hi":               errBadAttr,
+	"%h[raw=1;fgRed:2]hi":      errBadAttr,
+	"%h[raw=1;2":               errShort,
+	"%h[raw=fgRed+bgBlue]hi%r": exp("\x1b[fgRedm"+ti.Color(-1, caps.Blue)) + "hi" + exp(ti.Strings[caps.ExitAttributeMode]),
+}
+
+func TestRaw(t *testing.T) {
+	t.Parallel()
+	for k, v := range raws {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%h[raw=1;2;3]hi"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+}
+
 var highlightEdgeCases = map[string]string{
 	"%h[fgBrightBlack+%h[fgBlue]": exp(ti.Color(caps.BrightBlack, -1)) + errBadAttr,
 	"%h[":                   errShort,
@@ -157,3 +196,415 @@ func BenchmarkStrip(b *testing.B) {
 		Strip(s)
 	}
 }
+
+var rgbs = map[string]string{
+	"%h[fg#ff0010]hi":       exp("\x1b[38;2;255;0;16m") + "hi",
+	"%h[bg#0A0b0c+bold]hi":  exp("\x1b[48;2;10;11;12m"+ti.Strings[caps.EnterBoldMode]) + "hi",
+	"%h[fg#fff]hi":          errBadAttr,
+	"%h[fg#ff00100]hi":      errBadAttr,
+	"%h[fg#gg0010]hi":       errBadAttr,
+	"%h[fg#-f0010]hi":       errBadAttr,
+	"%h[fg#ff0010":          errShort,
+	"%h[fgRed+bg#000000]hi": exp(ti.Color(caps.Red, -1)+"\x1b[48;2;0;0;0m") + "hi",
+}
+
+func TestColorsRGB(t *testing.T) {
+	t.Parallel()
+	for k, v := range rgbs {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+}
+
+var defaults = map[string]string{
+	"%h[fgDefault]hi":                     exp("\x1b[39m") + "hi",
+	"%h[bgDefault]hi":                     exp("\x1b[49m") + "hi",
+	"%h[bold+fgDefault]hi":                exp(ti.Strings[caps.EnterBoldMode]+"\x1b[39m") + "hi",
+	"%h[fgRed+bgDefault]hi":               exp(ti.Color(caps.Red, -1)+"\x1b[49m") + "hi",
+	"%h[fgDefault+bgDefault]hi":           exp("\x1b[39;49m") + "hi",
+	"%h[bgDefault+fgDefault]hi":           exp("\x1b[39;49m") + "hi",
+	"%h[bold+bgDefault+fgDefault+bold]hi": exp(ti.Strings[caps.EnterBoldMode]+"\x1b[39;49m"+ti.Strings[caps.EnterBoldMode]) + "hi",
+	"%h[fgDefault]%h[bgDefault]hi":        exp("\x1b[39m\x1b[49m") + "hi",
+	"%h[fgDefaults]hi":                    errBadAttr,
+}
+
+func TestColorDefaults(t *testing.T) {
+	t.Parallel()
+	for k, v := range defaults {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+}
+
+var noVerbs = []string{
+	"",
+	"foo",
+	"%",
+	"foo%",
+	"%s: %d%%\n",
+	"%v%x%[1]q",
+}
+
+func TestNoVerbs(t *testing.T) {
+	t.Parallel()
+	for _, s := range noVerbs {
+		hl := newHighlighter(s, true)
+		exp := hl.run()
+		hl.free()
+		if r := Highlight(s); r != exp {
+			t.Errorf("Expected %q but result was %q", exp, r)
+		}
+		if r := Strip(s); r != exp {
+			t.Errorf("Expected %q but result was %q", exp, r)
+		}
+	}
+}
+
+var verbs = map[string]bool{
+	"":           false,
+	"%":          false,
+	"%h":         true,
+	"%r":         true,
+	"%%h[bold]":  false,
+	"%%%h[bold]": true,
+	"%%%%r":      false,
+	"foo%d%r":    true,
+	"%s%%r%q":    false,
+	"h[bold]":    false,
+	"%{bold}":    true,
+	"%}":         true,
+}
+
+func TestHasVerbs(t *testing.T) {
+	t.Parallel()
+	for k, v := range verbs {
+		if r := HasVerbs(k); r != v {
+			t.Errorf("Expected %t from %q but result was %t", v, k, r)
+		}
+	}
+}
+
+func BenchmarkHasVerbs(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		HasVerbs(noVerbsString)
+	}
+}
+
+func BenchmarkPrepareVerbs(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Prepare(noVerbsString + "%r")
+	}
+}
+
+const noVerbsString = "%s: request to %q failed after %d attempts (%.2f%%)\n"
+
+func BenchmarkHighlightNoVerbs(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Highlight(noVerbsString)
+	}
+}
+
+func BenchmarkHighlightNoVerbsParser(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hl := newHighlighter(noVerbsString, true)
+		hl.run()
+		hl.free()
+	}
+}
+
+var fills = map[string]string{
+	"%h[bgFillRed]hi%r":    exp(ti.Color(-1, caps.Red)+"\x1b[K") + "hi" + exp(ti.Strings[caps.ExitAttributeMode]),
+	"%h[bold+bgFill236]hi": exp(ti.Strings[caps.EnterBoldMode]+ti.Color(-1, 236)+"\x1b[K") + "hi",
+	"%h[bgFill]hi":         errBadAttr,
+	"%h[bgFill+1]hi":       errBadAttr,
+	"%h[bgFillBlu]hi":      errBadAttr,
+	"%h[fgFillRed]hi":      errBadAttr,
+}
+
+func TestFills(t *testing.T) {
+	t.Parallel()
+	for k, v := range fills {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%h[bgFillRed]hi%r"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+}
+
+var ctrls = map[string]string{
+	"%h[bell]error":           "\aerror",
+	"%h[clear]hi":             "\x1b[2J\x1b[Hhi",
+	"%h[fgRed+clearline]hi%r": exp(ti.Color(caps.Red, -1)) + "\x1b[2K\rhi" + exp(ti.Strings[caps.ExitAttributeMode]),
+	"%h[bells]hi":             errBadAttr,
+}
+
+func TestControls(t *testing.T) {
+	t.Parallel()
+	for k, v := range ctrls {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%h[bell+clear]hi"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+}
+
+var links = map[string]string{
+	"%h[link=http://x]x%h[linkend]":           "\x1b]8;;http://x\x1b\\x\x1b]8;;\x1b\\",
+	"%h[link=http://x+fgBlue]x%rx%h[linkend]": "\x1b]8;;http://x\x1b\\" + exp(ti.Color(caps.Blue, -1)) + "x" + exp(ti.Strings[caps.ExitAttributeMode]) + "x\x1b]8;;\x1b\\",
+	"%h[bold]%h[link=http://x]x%h[linkend]x":  exp(ti.Strings[caps.EnterBoldMode]) + "\x1b]8;;http://x\x1b\\x\x1b]8;;\x1b\\x",
+	"%h[linkend]x":                            "x",
+	"%h[link=]x":                              errBadAttr,
+	"%h[link=http://x\x1b]x":                  errBadAttr,
+	"%h[link=http://x/a%20b]x":                "\x1b]8;;http://x/a%%20b\x1b\\x",
+	"%h[link=http://x/?q=a+b]x":               errBadAttr,
+	"%h[link=http://x/?q=a%2Bb%5D]x":          "\x1b]8;;http://x/?q=a%%2Bb%%5D\x1b\\x",
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+	for k, v := range links {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%h[link=http://x]x%h[linkend]"); r != "x" {
+		t.Errorf("Expected %q but result was %q", "x", r)
+	}
+	r, _ := process("%h[link=http://x]x", true, "", AutoResetEnd)
+	if exp := "\x1b]8;;http://x\x1b\\x\x1b]8;;\x1b\\"; r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	var b bytes.Buffer
+	New(&b, true).Printf("%h[link=https://a.com/x%20y]z%h[linkend]")
+	if exp := "\x1b]8;;https://a.com/x%20y\x1b\\z\x1b]8;;\x1b\\"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+var modeOffs = map[string]string{
+	"%h[fgRed+-bold]hi":        exp(ti.Color(caps.Red, -1)) + "\x1b[22mhi",
+	"%h[-underline+-blink]hi":  "\x1b[24m\x1b[25mhi",
+	"%h[bold+-dim+-reverse]hi": exp(ti.Strings[caps.EnterBoldMode]) + "\x1b[22m\x1b[27mhi",
+	"%h[-bolder]hi":            errBadAttr,
+	"%h[-]hi":                  errBadAttr,
+}
+
+func TestModeOff(t *testing.T) {
+	t.Parallel()
+	for k, v := range modeOffs {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%h[-bold]hi"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+}
+
+var blocks = map[string]string{
+	"%{fgRed}panic:%} hi": exp(ti.Color(caps.Red, -1)) + "panic:" + exp(reset()) + " hi",
+	"%{bold}a %{fgRed}b%} c%}": exp(ti.Strings[caps.EnterBoldMode]) + "a " + exp(ti.Color(caps.Red, -1)) + "b" +
+		exp(reset()+ti.Strings[caps.EnterBoldMode]) + " c" + exp(reset()),
+	"%h[bold]%{fgRed}a%}b": exp(ti.Strings[caps.EnterBoldMode]+ti.Color(caps.Red, -1)) + "a" +
+		exp(reset()+ti.Strings[caps.EnterBoldMode]) + "b",
+	"%{fgRed}a%rb%}c": exp(ti.Color(caps.Red, -1)) + "a" + exp(reset()) + "b" + exp(reset()) + "c",
+	"%{fgRed+bold}a%{underline}b%}%}": exp(ti.Color(caps.Red, -1)+ti.Strings[caps.EnterBoldMode]) + "a" +
+		exp(ti.Strings[caps.EnterUnderlineMode]) + "b" +
+		exp(reset()+"\x1b[1;31m"+reset()),
+	"%}":           errNoBlock,
+	"%{fgRed}%}%}": exp(ti.Color(caps.Red, -1)+reset()) + errNoBlock,
+	"%{":           errShort,
+	"%{}":          errMissing,
+	"%{fgRed":      errShort,
+	"%{fgRed]":     errShort,
+	"%{fgFoo}a":    errBadAttr,
+}
+
+func TestBlocks(t *testing.T) {
+	t.Parallel()
+	for k, v := range blocks {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%{fgRed}a%{bold}b%}c%}d"); r != "abcd" {
+		t.Errorf("Expected %q but result was %q", "abcd", r)
+	}
+	r, _ := process("%{fgFoo}a%}", true, "", PassthroughUnknown)
+	if e := "%%{fgFoo}a" + exp(reset()); r != e {
+		t.Errorf("Expected %q but result was %q", e, r)
+	}
+}
+
+var cubes = map[string]string{
+	"%h[fgcube(5,0,0)]hi":  exp(ti.Color(196, -1)) + "hi",
+	"%h[bgcube(0,0,0)]hi":  exp(ti.Color(-1, 16)) + "hi",
+	"%h[fgcube(1,2,3)]hi":  exp(ti.Color(67, -1)) + "hi",
+	"%h[fgcube(6,0,0)]hi":  errBadAttr,
+	"%h[fgcube(5,0)]hi":    errBadAttr,
+	"%h[fgcube(5;0;0)]hi":  errBadAttr,
+	"%h[fgcube(5,0,0]hi":   errBadAttr,
+	"%h[fgcube(10,0,0)]hi": errBadAttr,
+}
+
+func TestCubes(t *testing.T) {
+	t.Parallel()
+	for k, v := range cubes {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := CubeIndex(5, 5, 5); r != 231 {
+		t.Errorf("Expected %d but result was %d", 231, r)
+	}
+	if r := CubeIndex(9, 0, 0); r != 196 {
+		t.Errorf("Expected %d but result was %d", 196, r)
+	}
+}
+
+var ansis = map[string]string{
+	"%h[fgansi0]hi":       exp("\x1b[30m") + "hi",
+	"%h[fgansi9]hi":       exp("\x1b[91m") + "hi",
+	"%h[bgansi7]hi":       exp("\x1b[47m") + "hi",
+	"%h[bgansi15+bold]hi": exp("\x1b[107m"+ti.Strings[caps.EnterBoldMode]) + "hi",
+	"%h[fgansi16]hi":      errBadAttr,
+	"%h[fgansi]hi":        errBadAttr,
+	"%h[fgansi+1]hi":      errBadAttr,
+	"%h[fgansi-1]hi":      errBadAttr,
+	"%h[fgansiRed]hi":     errBadAttr,
+}
+
+func TestColorsANSI(t *testing.T) {
+	t.Parallel()
+	for k, v := range ansis {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+}
+
+var underlines = map[string]string{
+	"%h[underline=curly]hi":             exp(ti.Strings[caps.EnterUnderlineMode]+"\x1b[4:3m") + "hi",
+	"%h[underline=single]hi":            exp(ti.Strings[caps.EnterUnderlineMode]) + "hi",
+	"%h[underline=dashed+ulcolorRed]hi": exp(ti.Strings[caps.EnterUnderlineMode]+"\x1b[4:5m\x1b[58;5;1m") + "hi",
+	"%h[ulcolor208+underline]hi":        exp("\x1b[58;5;208m"+ti.Strings[caps.EnterUnderlineMode]) + "hi",
+	"%h[ulcolor#ff0080]hi":              exp("\x1b[58;2;255;0;128m") + "hi",
+	"%h[ulcolorDefault]hi":              exp("\x1b[59m") + "hi",
+	"%h[underline=wavy]hi":              errBadAttr,
+	"%h[underline=]hi":                  errBadAttr,
+	"%h[ulcolor]hi":                     errBadAttr,
+	"%h[ulcolor256]hi":                  errBadAttr,
+	"%h[ulcolor#ff00]hi":                errBadAttr,
+	"%h[ulcolor#gg0000]hi":              errBadAttr,
+	"%h[ulcolorRedd]hi":                 errBadAttr,
+}
+
+func TestUnderlines(t *testing.T) {
+	t.Parallel()
+	for k, v := range underlines {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Strip("%h[underline=curly+ulcolorRed]hi"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+}
+
+var grays = map[string]string{
+	"%h[fggray0]hi":       exp(ti.Color(232, -1)) + "hi",
+	"%h[bggray23+bold]hi": exp(ti.Color(-1, 255)+ti.Strings[caps.EnterBoldMode]) + "hi",
+	"%h[fggray24]hi":      errBadAttr,
+	"%h[fggray]hi":        errBadAttr,
+	"%h[fggray-1]hi":      errBadAttr,
+	"%h[fggrayRed]hi":     errBadAttr,
+}
+
+func TestGrays(t *testing.T) {
+	t.Parallel()
+	for k, v := range grays {
+		if r := Highlight(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+	if r := Gray(5); r != 237 {
+		t.Errorf("Expected %d but result was %d", 237, r)
+	}
+	if r := Gray(30); r != 255 {
+		t.Errorf("Expected %d but result was %d", 255, r)
+	}
+}
+
+func TestRunb(t *testing.T) {
+	t.Parallel()
+	var inputs []string
+	for _, m := range []map[string]string{combinations, raws, highlightEdgeCases, rgbs, fills, ansis, underlines} {
+		for k := range m {
+			inputs = append(inputs, k)
+		}
+	}
+	inputs = append(inputs, noVerbs...)
+	inputs = append(inputs, s)
+	for _, in := range inputs {
+		for _, color := range []bool{true, false} {
+			exp := "foo" + Run(in, color)
+			if r := string(Runb([]byte("foo"), in, color)); r != exp {
+				t.Errorf("Expected %q from %q but result was %q", exp, in, r)
+			}
+		}
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	b.ReportAllocs()
+	buf := make([]byte, 0, 1024)
+	for i := 0; i < b.N; i++ {
+		buf = append(buf[:0], Run(s, true)...)
+	}
+}
+
+func BenchmarkRunb(b *testing.B) {
+	b.ReportAllocs()
+	buf := make([]byte, 0, 1024)
+	for i := 0; i < b.N; i++ {
+		buf = Runb(buf[:0], s, true)
+	}
+}
+
+// Not parallel because it changes global state.
+func TestSetVerbDelimiters(t *testing.T) {
+	if err := SetVerbDelimiters('<', '>'); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer SetVerbDelimiters('[', ']')
+	exp := exp(ti.Color(caps.Red, -1)+ti.Strings[caps.EnterBoldMode]) + "h[i]" + errInvalid
+	if r := Highlight("%h<fgRed+bold>h[i]%h[bold]"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r := Strip("%h<fgRed>hi%r"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+	if r, _ := CodesToVerb([]int{1}); r != "%h<bold>" {
+		t.Errorf("Expected %q but result was %q", "%h<bold>", r)
+	}
+	var b bytes.Buffer
+	New(&b, true).PrintfStyle(Style{Attrs: Bold}, "%s", "hi")
+	if exp := Highlight("%h<bold>hi%r"); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	for _, d := range [][2]byte{{'<', '<'}, {'a', '>'}, {'<', '+'}, {'%', '>'}, {'<', ' '}, {'(', ')'}, {'<', ','}, {'-', '>'}, {'{', '}'}, {'<', '}'}} {
+		if err := SetVerbDelimiters(d[0], d[1]); err == nil {
+			t.Errorf("Expected error from %q", d)
+		}
+	}
+}
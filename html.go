@@ -0,0 +1,165 @@
+package color
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// HTMLChunker converts colored output, which contains escape sequences rather than
+// highlight verbs, into fragments of HTML. It is meant for streaming the output of a
+// Printer to a web page, e.g. over server-sent events, by using it as the writer of the
+// Printer and sending the fragments returned by Flush.
+// Each fragment is self-contained: text in a style other than the default is wrapped in
+// a span with the style as inline CSS and every span is closed in the fragment it was
+// opened in, so fragments can be appended to a page in order without unbalanced tags.
+// The style active at the end of a fragment is carried forward into the next one.
+// SGR sequences are converted and other escape sequences are dropped. Text is escaped
+// for HTML but whitespace is kept, so the fragments belong in a pre element.
+// It is not safe for concurrent use.
+type HTMLChunker struct {
+	buf  bytes.Buffer // fragment being built
+	st   Style        // style at the end of buf
+	open bool         // a span is open in buf
+	pend []byte       // incomplete escape sequence or rune held back
+}
+
+// NewHTMLChunker returns a new HTMLChunker in the default style.
+func NewHTMLChunker() *HTMLChunker {
+	return new(HTMLChunker)
+}
+
+// Write converts p and appends it to the current fragment. Escape sequences and runes
+// split across writes are held back until they are complete, except that an incomplete
+// sequence longer than 256 bytes is dropped. It never returns an error.
+func (hc *HTMLChunker) Write(p []byte) (n int, err error) {
+	s := string(append(hc.pend, p...))
+	hc.pend = hc.pend[:0]
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if n := escapeLen(s[i:]); n > 0 {
+				if params, sn := sgrAt(s[i : i+n]); sn > 0 {
+					hc.setStyle(hc.st.apply(params))
+				}
+				i += n
+				continue
+			}
+			if escapePrefix(s[i:]) {
+				if len(s)-i <= maxEscapePrefix {
+					hc.pend = append(hc.pend, s[i:]...)
+				}
+				break
+			}
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(s) && s[j] != '\x1b' {
+			j++
+		}
+		if j == len(s) {
+			// Hold back an incomplete rune at the end.
+			for k := j - 1; k >= i && k > j-utf8.UTFMax; k-- {
+				if utf8.RuneStart(s[k]) {
+					if !utf8.FullRuneInString(s[k:]) {
+						hc.pend = append(hc.pend, s[k:]...)
+						j = k
+					}
+					break
+				}
+			}
+		}
+		hc.writeText(s[i:j])
+		if j < len(s) && s[j] != '\x1b' {
+			break
+		}
+		i = j
+	}
+	return len(p), nil
+}
+
+// Flush returns the current fragment with its span closed and starts the next one.
+func (hc *HTMLChunker) Flush() string {
+	if hc.open {
+		hc.buf.WriteString("</span>")
+		hc.open = false
+	}
+	s := hc.buf.String()
+	hc.buf.Reset()
+	return s
+}
+
+// setStyle changes the style of the text that follows to st.
+func (hc *HTMLChunker) setStyle(st Style) {
+	if st == hc.st {
+		return
+	}
+	if hc.open {
+		hc.buf.WriteString("</span>")
+		hc.open = false
+	}
+	hc.st = st
+}
+
+// writeText writes the text s in the current style.
+func (hc *HTMLChunker) writeText(s string) {
+	if s == "" {
+		return
+	}
+	if !hc.open && !hc.st.isDefault() {
+		fmt.Fprintf(&hc.buf, `<span style="%s">`, hc.st.css())
+		hc.open = true
+	}
+	hc.buf.WriteString(html.EscapeString(s))
+}
+
+// css returns the inline CSS of s. Reverse swaps the colors, using the CSS system
+// colors CanvasText and Canvas for the default foreground and background.
+func (s Style) css() string {
+	var decls []string
+	fg, bg := s.Fg.css(), s.Bg.css()
+	if s.Attrs&Reverse != 0 {
+		if fg == "" {
+			fg = "CanvasText"
+		}
+		if bg == "" {
+			bg = "Canvas"
+		}
+		fg, bg = bg, fg
+	}
+	if fg != "" {
+		decls = append(decls, "color:"+fg)
+	}
+	if bg != "" {
+		decls = append(decls, "background-color:"+bg)
+	}
+	if s.Attrs&Bold != 0 {
+		decls = append(decls, "font-weight:bold")
+	}
+	if s.Attrs&Dim != 0 {
+		decls = append(decls, "opacity:0.5")
+	}
+	switch s.Attrs & (Underline | Blink) {
+	case Underline:
+		decls = append(decls, "text-decoration:underline")
+	case Blink:
+		decls = append(decls, "text-decoration:blink")
+	case Underline | Blink:
+		decls = append(decls, "text-decoration:underline blink")
+	}
+	return strings.Join(decls, ";")
+}
+
+// css returns the CSS color of c or an empty string if it is the default.
+func (c Color) css() string {
+	switch c.kind {
+	case kindIndex:
+		c := RGBForIndex(c.n)
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	case kindRGB:
+		return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+package color
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLChunker(t *testing.T) {
+	t.Parallel()
+	hc := NewHTMLChunker()
+	hc.Write([]byte("a<b \x1b[1;31mfoo"))
+	hc.Write([]byte(" bar\x1b["))
+	exp := `a&lt;b <span style="color:#cd0000;font-weight:bold">foo bar</span>`
+	if r := hc.Flush(); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	hc.Write([]byte("4mbaz\x1b[0m \x1b]8;;http://x\x1b\\qux\xe4\xb8"))
+	hc.Write([]byte("\x96\x1b[7;38;2;1;2;3mend"))
+	exp = `<span style="color:#cd0000;font-weight:bold;text-decoration:underline">baz</span> qux世` +
+		`<span style="color:Canvas;background-color:#010203">end</span>`
+	if r := hc.Flush(); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r := hc.Flush(); r != "" {
+		t.Errorf("Expected %q but result was %q", "", r)
+	}
+	hc.Write([]byte("\x1b[0mfoo\x1b]0;" + strings.Repeat("a", 200)))
+	hc.Write([]byte(strings.Repeat("a", 200)))
+	hc.Write([]byte("b"))
+	if r := hc.Flush(); r != "foob" || len(hc.pend) != 0 {
+		t.Errorf("Expected %q but result was %q", "foob", r)
+	}
+}
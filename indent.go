@@ -0,0 +1,135 @@
+package color
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SetIndent makes p indent every line of its output by n spaces printed in the style
+// spec, the list of attributes as it would appear between the brackets of the highlight
+// verb, or in the terminal default if spec is empty. The indent is written before the
+// first character of each line that is not a newline, so empty lines are left alone, and
+// a line started by an earlier call is not indented again. It is written outside the
+// styling of the output: attributes active at the start of a line are reset before the
+// indent and set again after it. A carriage return starts the line over, so the line
+// is indented again. If p writes to a writer created by NewWrapWriter, the lines it
+// breaks are indented as well. PrintfWidth includes the indent in the width.
+// An n less than 1 removes the indent.
+func (p *Printer) SetIndent(n int, spec string) {
+	var indent string
+	if n > 0 {
+		indent = strings.Repeat(" ", n)
+		if spec != "" && p.ColorEnabled() {
+			indent = Highlight(verb(spec)) + indent + reset()
+		}
+	} else {
+		n = 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.indent, p.indentWidth = indent, n
+	if ww, ok := p.out.(*wrapWriter); ok {
+		ww.indent, ww.indentWidth = indent, n
+	}
+}
+
+// SetLinePrefixFunc makes p call f for the prefix of every line of its output, e.g. a
+// counter or a timestamp to correlate the output with other output. The prefix is
+// written when a line starts, before the first character that is not a newline and
+// before the indent of p, including every line within a single call, so a line
+// without a trailing newline is prefixed when it starts rather than when it ends.
+// It is written outside the styling of the output, like the indent, and it may contain
+// escape sequences of its own, e.g. from Style.Sprint, which are removed if color
+// output is disabled. PrintfWidth includes the prefix in the width.
+// The function is called with p locked, so it must not use p. The lock is held until
+// the prefixed output is written, so the prefixes of concurrent calls appear in the
+// order they were made. A nil f removes the prefix.
+func (p *Printer) SetLinePrefixFunc(f func() string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.linePrefix = f
+}
+
+// direct returns true if output printed with flags can be written to the underlying
+// writer as it is formatted.
+func (p *Printer) direct(flags int) bool {
+	if flags&MinifyOutput != 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.prefixed()
+}
+
+// prefixed returns true if the lines of the output of p are prefixed or indented.
+// The caller must hold p.mu.
+func (p *Printer) prefixed() bool {
+	return p.indent != "" || p.linePrefix != nil
+}
+
+// lineStart returns what p writes at the start of each line.
+// The caller must hold p.mu.
+func (p *Printer) lineStart() string {
+	if p.linePrefix == nil {
+		return p.indent
+	}
+	prefix := p.linePrefix()
+	if !p.ColorEnabled() {
+		prefix = stripEscapes(prefix)
+	}
+	return prefix + p.indent
+}
+
+// prefixLines returns s with the line prefix and the indent of p written at the start
+// of every line. Escape sequences at the start of a line are written after them so
+// that they apply to the line rather than the prefix. The caller must hold p.mu.
+func (p *Printer) prefixLines(s string) string {
+	var (
+		buf     strings.Builder
+		pending strings.Builder // escape sequences at the start of the line
+		prior   sgrState        // SGR state before pending
+	)
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\x1b':
+			if n := escapeLen(s[i:]); n > 0 {
+				if p.midLine {
+					buf.WriteString(s[i : i+n])
+				} else {
+					if pending.Len() == 0 {
+						prior = p.active.clone()
+					}
+					pending.WriteString(s[i : i+n])
+				}
+				p.active.track(s[i : i+n])
+				i += n
+				continue
+			}
+		case '\n', '\r':
+			p.midLine = false
+			buf.WriteString(pending.String())
+			pending.Reset()
+			buf.WriteByte(s[i])
+			i++
+			continue
+		}
+		if !p.midLine {
+			if pending.Len() == 0 {
+				prior = p.active.clone()
+			}
+			if prior.active() {
+				buf.WriteString(Reset + p.lineStart() + prior.sgr())
+			} else {
+				buf.WriteString(p.lineStart())
+			}
+			buf.WriteString(pending.String())
+			pending.Reset()
+			p.midLine = true
+		}
+		_, n := utf8.DecodeRuneInString(s[i:])
+		buf.WriteString(s[i : i+n])
+		i += n
+	}
+	buf.WriteString(pending.String())
+	return buf.String()
+}
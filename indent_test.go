@@ -0,0 +1,136 @@
+package color
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetIndent(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, false)
+	p.SetIndent(2, "fgBlue")
+	p.Printf("foo\n\nbar")
+	p.Println(" baz")
+	p.Print("qux\rquux\n")
+	exp := "  foo\n\n  bar baz\n  qux\r  quux\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+
+	b.Reset()
+	p = New(&b, true)
+	p.SetIndent(2, "fgBlue")
+	p.Printf("%h[bold]foo\nbar%r\n")
+	indent := "\x1b[34m  " + reset()
+	exp = indent + "\x1b[1mfoo\n" + Reset + indent + "\x1b[1mbar" + reset() + "\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+
+	b.Reset()
+	p.SetIndent(0, "")
+	p.Printf("foo\n")
+	if exp = "foo\n"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestSetIndentWidth(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, false)
+	p.SetIndent(4, "")
+	if w, _ := p.PrintfWidth("世界"); w != 8 {
+		t.Errorf("Expected %d but result was %d", 8, w)
+	}
+}
+
+func TestSetIndentWrapWriter(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(NewWrapWriter(&b, 6), false)
+	p.SetIndent(2, "")
+	p.Printf("foobarbaz\nqux\n")
+	exp := "  foob\n  arba\n  z\n  qux\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestSetLinePrefixFunc(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, false)
+	n := 0
+	p.SetLinePrefixFunc(func() string {
+		n++
+		return Style{Fg: PaletteColor(4)}.Sprint(n, " ")
+	})
+	p.SetIndent(1, "")
+	p.Printf("foo\nbar")
+	p.Printf(" baz\n\nqux\n")
+	exp := "1  foo\n2  bar baz\n\n3  qux\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+
+	b.Reset()
+	p = New(&b, true)
+	p.SetLinePrefixFunc(func() string {
+		return "\x1b[34m>" + Reset
+	})
+	p.Printf("%h[bold]foo\nbar%r\n")
+	exp = "\x1b[34m>" + Reset + "\x1b[1mfoo\n" + Reset + "\x1b[34m>" + Reset + "\x1b[1mbar" + reset() + "\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+
+	b.Reset()
+	p.SetLinePrefixFunc(nil)
+	p.Printf("foo\n")
+	if exp = "foo\n"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+// slowWriter waits before every write so that concurrent writes overlap.
+type slowWriter struct {
+	b bytes.Buffer
+}
+
+func (w *slowWriter) Write(b []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return w.b.Write(b)
+}
+
+func TestSetLinePrefixFuncConcurrent(t *testing.T) {
+	t.Parallel()
+	var w slowWriter
+	p := New(&w, false)
+	n := 0
+	p.SetLinePrefixFunc(func() string {
+		n++
+		return strconv.Itoa(n) + " "
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Printf("foo\n")
+		}()
+	}
+	wg.Wait()
+	var exp strings.Builder
+	for i := 1; i <= 50; i++ {
+		exp.WriteString(strconv.Itoa(i) + " foo\n")
+	}
+	if w.b.String() != exp.String() {
+		t.Errorf("Expected %q but result was %q", exp.String(), w.b.String())
+	}
+}
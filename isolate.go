@@ -0,0 +1,168 @@
+package color
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// isolateArgs wraps each argument in a that is formatted while attributes are active in
+// format, the processed format, so that the attributes are set again after every reset
+// in the formatted argument. Color output must be enabled.
+func isolateArgs(format string, a []interface{}) {
+	active := make([]string, len(a))
+	// An argument is left alone if it is plain, if it is used as a width or precision or
+	// by %T or %p, which wrapping would break, or if it is used in more than one place
+	// with different attributes active.
+	skip := make([]bool, len(a))
+	used := make([]bool, len(a))
+	var attrs string // sequences active at the current position of format
+	argNum := 0
+	for i := 0; i < len(format); {
+		switch {
+		case strings.HasPrefix(format[i:], reset()):
+			attrs = ""
+			i += len(reset())
+			continue
+		case format[i] == '\x1b':
+			if params, n := sgrAt(format[i:]); n > 0 {
+				if params == "" || params == "0" {
+					attrs = ""
+				} else {
+					attrs += format[i : i+n]
+				}
+				i += n
+				continue
+			}
+		case format[i] == '%':
+			var ok bool
+			i, argNum, ok = scanDirective(format, i+1, argNum, func(arg int, verb rune) {
+				if arg < 0 || arg >= len(a) {
+					return
+				}
+				if verb == '*' || verb == 'T' || verb == 'p' || (used[arg] && active[arg] != attrs) {
+					skip[arg] = true
+				}
+				used[arg] = true
+				active[arg] = attrs
+			})
+			if !ok {
+				// Bad directive, fmt reports it so further arguments may not line up.
+				i = len(format)
+			}
+			continue
+		}
+		i++
+	}
+	for i, v := range a {
+		if active[i] != "" && !skip[i] && !plainArg(v) {
+			a[i] = isolator{v, active[i]}
+		}
+	}
+}
+
+// scanDirective scans the fmt directive in format that starts at i, just after the '%',
+// and calls use with the index of every argument that the directive consumes and the verb
+// it is consumed by, '*' for a width or precision. It returns the index after the
+// directive, the next argument number and whether the directive was understood.
+func scanDirective(format string, i, argNum int, use func(arg int, verb rune)) (int, int, bool) {
+	for i < len(format) && strings.IndexByte("+-# 0", format[i]) != -1 {
+		i++
+	}
+	argIndex := func() bool {
+		if i >= len(format) || format[i] != '[' {
+			return true
+		}
+		j := strings.IndexByte(format[i:], ']')
+		if j == -1 {
+			return false
+		}
+		n := 0
+		for _, ch := range format[i+1 : i+j] {
+			if ch < '0' || ch > '9' {
+				return false
+			}
+			n = n*10 + int(ch-'0')
+		}
+		if j == 1 || n == 0 {
+			return false
+		}
+		argNum = n - 1
+		i += j + 1
+		return true
+	}
+	number := func() {
+		if i < len(format) && format[i] == '*' {
+			use(argNum, '*')
+			argNum++
+			i++
+			return
+		}
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+	}
+	if !argIndex() {
+		return i, argNum, false
+	}
+	number()
+	if i < len(format) && format[i] == '.' {
+		i++
+		if !argIndex() {
+			return i, argNum, false
+		}
+		number()
+	}
+	if !argIndex() || i >= len(format) {
+		return i, argNum, false
+	}
+	verb, n := utf8.DecodeRuneInString(format[i:])
+	i += n
+	if verb != '%' {
+		use(argNum, verb)
+		argNum++
+	}
+	return i, argNum, true
+}
+
+// isolator wraps a value so that the attributes in active are set again after every reset
+// in it when it is formatted by fmt.
+type isolator struct {
+	v      interface{}
+	active string
+}
+
+// Format formats iso.v according to the verb and the flags in f and then writes it
+// to f with iso.active after every reset.
+func (iso isolator) Format(f fmt.State, verb rune) {
+	io.WriteString(f, reapply(fmt.Sprintf(directive(f, verb), iso.v), iso.active))
+}
+
+// reapply returns s with active written after every reset in s.
+func reapply(s, active string) string {
+	if strings.IndexByte(s, '\x1b') == -1 {
+		return s
+	}
+	var b bytes.Buffer
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], reset()) {
+			b.WriteString(reset())
+			b.WriteString(active)
+			i += len(reset())
+			continue
+		}
+		if params, n := sgrAt(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			if params == "" || params == "0" {
+				b.WriteString(active)
+			}
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package color
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestIsolateArgs(t *testing.T) {
+	t.Parallel()
+	if tiErr != nil {
+		t.Skip("no terminfo")
+	}
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(IsolateArgs)
+	red := Highlight("%h[fgRed]")
+	bold := Highlight("%h[bold]")
+	arg := "\x1b[1mfoo\x1b[0m" + reset()
+	formats := map[string]string{
+		"%h[fgRed]%s bar%r":         red + "\x1b[1mfoo\x1b[0m" + red + reset() + red + " bar" + reset(),
+		"%s %h[fgRed]bar%r":         arg + " " + red + "bar" + reset(),
+		"%h[fgRed]%r%s":             red + reset() + arg,
+		"%h[fgRed]%[1]s%r%[1]s":     red + arg + reset() + arg,
+		"%h[fgRed]%[1]s%[1]s%r":     red + "\x1b[1mfoo\x1b[0m" + red + reset() + red + "\x1b[1mfoo\x1b[0m" + red + reset() + red + reset(),
+		"%h[fgRed+bold]%-*s|":       red + bold + "\x1b[1mfoo\x1b[0m" + red + bold + reset() + red + bold + "|",
+		"%h[fgRed]%%%h[bold]%.3q%r": red + "%" + bold + `"\x1b[1"` + reset(),
+	}
+	for k, v := range formats {
+		b.Reset()
+		if k == "%h[fgRed+bold]%-*s|" {
+			p.Printf(k, 2, arg)
+		} else {
+			p.Printf(k, arg)
+		}
+		if b.String() != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, b.String())
+		}
+	}
+	b.Reset()
+	p.Printf("%h[fgRed]%T %v %d %p%r", 42, "x", 7, p)
+	if exp := red + fmt.Sprintf("int x 7 %p", p) + reset(); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printfp(Prepare("%h[fgRed]%s bar%r"), arg)
+	if exp := formats["%h[fgRed]%s bar%r"]; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
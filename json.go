@@ -0,0 +1,104 @@
+package color
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// jsonStyles holds the styles of the JSON tokens, see themedStart.
+var jsonStyles = [...]struct {
+	name, spec string
+}{
+	jsonKey:    {"jsonKey", "fgBlue+bold"},
+	jsonString: {"jsonString", "fgGreen"},
+	jsonNumber: {"jsonNumber", "fgCyan"},
+	jsonBool:   {"jsonBool", "fgYellow"},
+	jsonNull:   {"jsonNull", "fgMagenta"},
+}
+
+// The types of the JSON tokens that are colored.
+const (
+	jsonKey = iota
+	jsonString
+	jsonNumber
+	jsonBool
+	jsonNull
+)
+
+// JSON returns data, which must be valid JSON, with object keys, strings, numbers,
+// booleans and null colored with the styles registered as jsonKey, jsonString,
+// jsonNumber, jsonBool and jsonNull, or blue and bold, green, cyan, yellow and magenta
+// if they are not registered. Each colored token is followed by a reset.
+// The whitespace of data is preserved, use json.Indent first to indent it.
+// The color argument dictates whether color output is enabled, if it is not,
+// data is returned unchanged. It returns an error if data is not valid JSON.
+func JSON(data []byte, color bool) ([]byte, error) {
+	if !json.Valid(data) {
+		return nil, errors.New("color: invalid JSON")
+	}
+	if !color || tiErr != nil {
+		return data, nil
+	}
+	var starts [len(jsonStyles)]string
+	for i, js := range jsonStyles {
+		starts[i] = themedStart(js.name, js.spec)
+	}
+	var b bytes.Buffer
+	b.Grow(len(data) * 2)
+	for i := 0; i < len(data); {
+		var typ, end int
+		switch ch := data[i]; {
+		case ch == '"':
+			end = jsonStringEnd(data, i)
+			typ = jsonString
+			if isJSONKey(data[end:]) {
+				typ = jsonKey
+			}
+		case ch == '-' || ch >= '0' && ch <= '9':
+			end = i + 1
+			for end < len(data) && bytes.IndexByte([]byte("+-.eE0123456789"), data[end]) != -1 {
+				end++
+			}
+			typ = jsonNumber
+		case ch == 't' || ch == 'f':
+			end = i + len("true")
+			if ch == 'f' {
+				end++
+			}
+			typ = jsonBool
+		case ch == 'n':
+			end = i + len("null")
+			typ = jsonNull
+		default:
+			b.WriteByte(ch)
+			i++
+			continue
+		}
+		b.WriteString(starts[typ])
+		b.Write(data[i:end])
+		b.WriteString(reset())
+		i = end
+	}
+	return b.Bytes(), nil
+}
+
+// jsonStringEnd returns the position after the end of the JSON string that starts at i.
+func jsonStringEnd(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// isJSONKey returns true if rest, what follows a JSON string, starts with a colon
+// after any whitespace, which makes the string an object key.
+func isJSONKey(rest []byte) bool {
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
@@ -0,0 +1,37 @@
+package color
+
+import "testing"
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+	const data = `{"a\"b": [1.5e3, -2, "x:y", true, false, null], "c" : {}}`
+	key := Highlight(verb("fgBlue+bold"))
+	str := Highlight(verb("fgGreen"))
+	num := Highlight(verb("fgCyan"))
+	boo := Highlight(verb("fgYellow"))
+	null := Highlight(verb("fgMagenta"))
+	r := reset()
+	exp := `{` + key + `"a\"b"` + r + `: [` + num + `1.5e3` + r + `, ` + num + `-2` + r + `, ` +
+		str + `"x:y"` + r + `, ` + boo + `true` + r + `, ` + boo + `false` + r + `, ` + null + `null` + r +
+		`], ` + key + `"c"` + r + ` : {}}`
+	if tiErr != nil {
+		exp = data
+	}
+	out, err := JSON([]byte(data), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != exp {
+		t.Errorf("Expected %q but result was %q", exp, out)
+	}
+	out, err = JSON([]byte(data), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != data {
+		t.Errorf("Expected %q but result was %q", data, out)
+	}
+	if _, err := JSON([]byte(`{"a": }`), true); err == nil {
+		t.Errorf("Expected an error from invalid JSON")
+	}
+}
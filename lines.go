@@ -0,0 +1,44 @@
+package color
+
+import (
+	"bytes"
+	"strings"
+)
+
+// StyleLines returns text with every line wrapped in the style of spec, so that the style
+// is set again at the start of each line and reset at its end. The spec argument is the
+// list of attributes as it would appear between the brackets of the highlight verb,
+// e.g. "fgRed+bold". Lines end with "\n" or "\r\n", the line endings are left outside
+// of the style and empty lines, including the one after a trailing newline, are left
+// alone. The color argument dictates whether color output is enabled, if it is not,
+// text is returned unchanged.
+func StyleLines(spec, text string, color bool) string {
+	start := Run(verb(spec), color)
+	if start == "" {
+		return text
+	}
+	var b bytes.Buffer
+	for _, l := range strings.SplitAfter(text, "\n") {
+		writeStyledLine(&b, start, l)
+	}
+	return b.String()
+}
+
+// writeStyledLine writes the line l wrapped in the escape sequences start and a reset
+// to b, leaving the line ending outside of them. Empty lines are written unchanged.
+func writeStyledLine(b *bytes.Buffer, start, l string) {
+	end := len(l)
+	if strings.HasSuffix(l, "\r\n") {
+		end -= 2
+	} else if strings.HasSuffix(l, "\n") {
+		end--
+	}
+	if end == 0 || start == "" {
+		b.WriteString(l)
+		return
+	}
+	b.WriteString(start)
+	b.WriteString(l[:end])
+	b.WriteString(reset())
+	b.WriteString(l[end:])
+}
@@ -0,0 +1,27 @@
+package color
+
+import "testing"
+
+func TestStyleLines(t *testing.T) {
+	t.Parallel()
+	st := Highlight("%h[fgRed]")
+	r := reset()
+	if tiErr != nil {
+		st, r = "", ""
+	}
+	styled := map[string]string{
+		"":                     "",
+		"foo":                  st + "foo" + r,
+		"foo\nbar\n":           st + "foo" + r + "\n" + st + "bar" + r + "\n",
+		"foo\r\n\r\nbar\r\n\n": st + "foo" + r + "\r\n\r\n" + st + "bar" + r + "\r\n\n",
+		"\nfoo":                "\n" + st + "foo" + r,
+	}
+	for k, v := range styled {
+		if res := StyleLines("fgRed", k, true); res != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, res)
+		}
+		if res := StyleLines("fgRed", k, false); res != k {
+			t.Errorf("Expected %q but result was %q", k, res)
+		}
+	}
+}
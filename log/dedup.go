@@ -0,0 +1,75 @@
+package log
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nhooyr/color"
+)
+
+var defaultSummary = color.Prepare("(repeated %d times)")
+
+// SetDedup collapses identical lines written within window of the first one.
+// The line is written once and the number of times it was suppressed is written
+// as a summary line when the window closes or a different line is written.
+// Lines are compared after the highlight verbs and arguments are processed.
+// A window of zero disables it, which is the default.
+func (l *Logger) SetDedup(window time.Duration) {
+	l.out.Lock()
+	defer l.out.Unlock()
+	l.out.flushRepeats()
+	l.out.window = window
+}
+
+// SetDedupSummary sets the format of the summary of repeated lines.
+// It is given the number of times the line was suppressed.
+// The default is "(repeated %d times)".
+func (l *Logger) SetDedupSummary(f *color.Format) {
+	l.out.Lock()
+	defer l.out.Unlock()
+	l.out.summary = f
+}
+
+// SetDedup sets the deduplication window of the standard Logger.
+func SetDedup(window time.Duration) {
+	std.SetDedup(window)
+}
+
+// SetDedupSummary sets the format of the summary of repeated lines of the standard Logger.
+func SetDedupSummary(f *color.Format) {
+	std.SetDedupSummary(f)
+}
+
+// write writes p unless it repeats the last line within the window.
+// The caller must hold the lock.
+func (lw *lineWriter) write(p []byte) (n int, err error) {
+	if lw.window == 0 {
+		return lw.w.Write(p)
+	}
+	if string(p) == lw.last {
+		lw.repeats++
+		return len(p), nil
+	}
+	lw.flushRepeats()
+	lw.last = string(p)
+	gen := lw.gen
+	time.AfterFunc(lw.window, func() {
+		lw.Lock()
+		defer lw.Unlock()
+		if lw.gen == gen {
+			lw.flushRepeats()
+		}
+	})
+	return lw.w.Write(p)
+}
+
+// flushRepeats writes the summary of the last line if it was repeated
+// and forgets it. The caller must hold the lock.
+func (lw *lineWriter) flushRepeats() {
+	if lw.repeats > 0 {
+		fmt.Fprintf(lw.w, lw.summary.Get(lw.color)+"\n", lw.repeats)
+	}
+	lw.last = ""
+	lw.repeats = 0
+	lw.gen++
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nhooyr/color"
+)
+
+// syncBuffer is a bytes.Buffer that is safe to use from the dedup timers.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.String()
+}
+
+func TestSetDedup(t *testing.T) {
+	t.Parallel()
+	var b syncBuffer
+	l := New(&b, false)
+	l.SetDedup(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Printf("%h[fgRed]disk full%r: %d", 1)
+	}
+	l.Println("other")
+	l.Println("other")
+	l.Print("other")
+	l.SetDedup(0)
+	l.Println("other")
+	l.Println("other")
+	exp := "disk full: 1\n(repeated 2 times)\nother\n(repeated 2 times)\nother\nother\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestSetDedupWindow(t *testing.T) {
+	t.Parallel()
+	var b syncBuffer
+	l := New(&b, true)
+	l.SetDedupSummary(color.Prepare("%h[dim]repeated %d times%r"))
+	l.SetDedup(10 * time.Millisecond)
+	l.Println("foo")
+	l.Println("foo")
+	time.Sleep(100 * time.Millisecond)
+	l.Println("foo")
+	exp := "foo\n" + color.Run("%h[dim]repeated 1 times%r", true) + "\nfoo\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
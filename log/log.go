@@ -4,15 +4,18 @@ It defines a Logger type with methods for formatting and printing output.
 
 It also defines a global standard Logger that writes to standard error. Color output
 will only be enabled if standard error is a terminal.
-Use the helper functions Print[f|ln|p], Fatal[f|ln|p], Panicf[f|ln|p], SetOutput and SetColor to access it.
+Use the helper functions Print[f|ln|p], Fatal[f|ln|p], Panicf[f|ln|p], SetOutput, SetColor, SetDedup and SetAutoReset to access it.
 */
 package log
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/nhooyr/color"
 )
@@ -21,15 +24,17 @@ import (
 type Logger struct {
 	out *lineWriter // ensures output is written on separate lines
 
-	mu    sync.Mutex
-	color bool // enable color output
+	mu          sync.Mutex
+	color       bool // enable color output
+	noReset     bool // do not reset the attributes before exiting or panicking
+	noAutoReset bool // do not reset the attributes left active by a format
 }
 
 // New creates a new Logger. The out argument sets the
 // destination to which log data will be written.
 // The color argument dictates whether color output is enabled.
 func New(w io.Writer, color bool) *Logger {
-	return &Logger{out: &lineWriter{w: w}, color: color}
+	return &Logger{out: &lineWriter{w: w, color: color, summary: defaultSummary}, color: color}
 }
 
 // Printf processes the highlight verbs in format and then calls
@@ -38,7 +43,7 @@ func New(w io.Writer, color bool) *Logger {
 func (l *Logger) Printf(format string, v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
-	format = color.Run(format, l.color)
+	format = l.run(format)
 	l.mu.Unlock()
 	fmt.Fprintf(l.out, format, v...)
 }
@@ -47,7 +52,7 @@ func (l *Logger) Printf(format string, v ...interface{}) {
 func (l *Logger) Printfp(f *color.Format, v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
-	format := f.Get(l.color)
+	format := l.get(f)
 	l.mu.Unlock()
 	fmt.Fprintf(l.out, format, v...)
 }
@@ -71,11 +76,14 @@ func (l *Logger) Println(v ...interface{}) {
 }
 
 // Fatalf is equivalent to l.Printf() followed by a call to os.Exit(1).
+// See SetResetOnExit.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
 	format = color.Run(format, l.color)
-	fmt.Fprintf(l.out, format, v...)
+	reset := l.resetOnExit()
+	l.mu.Unlock()
+	l.out.writeLast(fmt.Sprintf(format, v...), reset)
 	os.Exit(1)
 }
 
@@ -84,7 +92,9 @@ func (l *Logger) Fatalfp(f *color.Format, v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
 	format := f.Get(l.color)
-	fmt.Fprintf(l.out, format, v...)
+	reset := l.resetOnExit()
+	l.mu.Unlock()
+	l.out.writeLast(fmt.Sprintf(format, v...), reset)
 	os.Exit(1)
 }
 
@@ -92,7 +102,9 @@ func (l *Logger) Fatalfp(f *color.Format, v ...interface{}) {
 func (l *Logger) Fatal(v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
-	fmt.Fprint(l.out, v...)
+	reset := l.resetOnExit()
+	l.mu.Unlock()
+	l.out.writeLast(fmt.Sprint(v...), reset)
 	os.Exit(1)
 }
 
@@ -100,18 +112,22 @@ func (l *Logger) Fatal(v ...interface{}) {
 func (l *Logger) Fatalln(v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
-	fmt.Fprintln(l.out, v...)
+	reset := l.resetOnExit()
+	l.mu.Unlock()
+	l.out.writeLast(fmt.Sprintln(v...), reset)
 	os.Exit(1)
 }
 
 // Panicf is equivalent to l.Printf() followed by a call to panic().
+// See SetResetOnExit.
 func (l *Logger) Panicf(format string, v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
 	format = color.Run(format, l.color)
+	reset := l.resetOnExit()
 	l.mu.Unlock()
 	s := fmt.Sprintf(format, v...)
-	l.out.WriteString(s)
+	l.out.writeLast(s, reset)
 	panic(s)
 }
 
@@ -120,9 +136,10 @@ func (l *Logger) Panicfp(f *color.Format, v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
 	format := f.Get(l.color)
+	reset := l.resetOnExit()
 	l.mu.Unlock()
 	s := fmt.Sprintf(format, v...)
-	l.out.WriteString(s)
+	l.out.writeLast(s, reset)
 	panic(s)
 }
 
@@ -130,9 +147,10 @@ func (l *Logger) Panicfp(f *color.Format, v ...interface{}) {
 func (l *Logger) Panic(v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
+	reset := l.resetOnExit()
 	l.mu.Unlock()
 	s := fmt.Sprint(v...)
-	l.out.WriteString(s)
+	l.out.writeLast(s, reset)
 	panic(s)
 }
 
@@ -140,9 +158,10 @@ func (l *Logger) Panic(v ...interface{}) {
 func (l *Logger) Panicln(v ...interface{}) {
 	l.mu.Lock()
 	color.ExpandFormats(l.color, v)
+	reset := l.resetOnExit()
 	l.mu.Unlock()
 	s := fmt.Sprintln(v...)
-	l.out.WriteString(s)
+	l.out.writeLast(s, reset)
 	panic(s)
 }
 
@@ -150,20 +169,128 @@ func (l *Logger) Panicln(v ...interface{}) {
 func (l *Logger) SetOutput(w io.Writer) {
 	l.out.Lock()
 	defer l.out.Unlock()
+	l.out.flushRepeats()
 	l.out.w = w
 }
 
+// SetResetOnExit sets whether the Fatal and Panic methods write the sequence that resets
+// all attributes after their output when color output is enabled, so that the terminal is
+// not left colored by an unbalanced style. It is enabled by default.
+func (l *Logger) SetResetOnExit(reset bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.noReset = !reset
+}
+
+// resetOnExit returns true if the attributes should be reset before exiting or
+// panicking. The caller must hold the lock.
+func (l *Logger) resetOnExit() bool {
+	return l.color && !l.noReset
+}
+
+// SetAutoReset sets whether the Printf and Printfp methods write the sequence that resets
+// all attributes at the end of a format that leaves attributes active, before its trailing
+// newline if it has one, so that they do not apply to the lines logged afterwards. It has
+// no effect when color output is disabled. It is enabled by default.
+func (l *Logger) SetAutoReset(reset bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.noAutoReset = !reset
+}
+
+// run processes the highlight verbs in format and adds a reset as required by
+// SetAutoReset. The caller must hold the lock.
+func (l *Logger) run(format string) string {
+	if !l.color || l.noAutoReset {
+		return color.Run(format, l.color)
+	}
+	return l.get(color.Prepare(format))
+}
+
+// get returns the string of f and adds a reset as required by SetAutoReset.
+// The caller must hold the lock.
+func (l *Logger) get(f *color.Format) string {
+	format := f.Get(l.color)
+	if !l.color || l.noAutoReset || !f.Active() {
+		return format
+	}
+	if strings.HasSuffix(format, "\n") {
+		return format[:len(format)-1] + color.Reset + "\n"
+	}
+	return format + color.Reset
+}
+
 // SetColor sets whether colored output is enabled.
 func (l *Logger) SetColor(color bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.color = color
+	l.out.Lock()
+	l.out.color = color
+	l.out.Unlock()
+}
+
+// Writer returns an io.Writer that logs each line written to it with l.Print.
+// Partial lines are buffered until they are completed by a newline.
+// The highlight verbs are not processed in the lines.
+// It is safe to write to the io.Writer concurrently.
+func (l *Logger) Writer() io.Writer {
+	return &logWriter{l: l}
+}
+
+// logWriter splits everything written to it into lines and logs them.
+type logWriter struct {
+	mu  sync.Mutex
+	l   *Logger
+	buf []byte // partial line
+}
+
+// Write logs every line completed by p and buffers what is left.
+func (lw *logWriter) Write(p []byte) (n int, err error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.buf = append(lw.buf, p...)
+	i := 0
+	for {
+		j := bytes.IndexByte(lw.buf[i:], '\n')
+		if j == -1 {
+			break
+		}
+		lw.l.Print(string(lw.buf[i : i+j+1]))
+		i += j + 1
+	}
+	lw.buf = append(lw.buf[:0], lw.buf[i:]...)
+	return len(p), nil
 }
 
 // lineWriter ensures that each Write to the underlying writer will end on a newline.
+// It also collapses repeated lines, see Logger.SetDedup.
 type lineWriter struct {
 	sync.Mutex           // ensures atomic writes
 	w          io.Writer // underlying writer
+
+	color   bool          // enable color output in the summary
+	summary *color.Format // summary of repeated lines
+	window  time.Duration // zero disables deduplication
+	last    string        // last line written while deduplicating
+	repeats int           // number of times last was suppressed
+	gen     int           // invalidates the timers of previous lines
+}
+
+// writeLast writes s, the last output before exiting or panicking, followed by the
+// sequence that resets all attributes if reset is true. It is never suppressed by
+// the deduplication.
+func (lw *lineWriter) writeLast(s string, reset bool) {
+	lw.Lock()
+	defer lw.Unlock()
+	lw.flushRepeats()
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		s += "\n"
+	}
+	if reset {
+		s += color.Reset
+	}
+	io.WriteString(lw.w, s)
 }
 
 // Write writes to the underlying writer but ensures that the write ends on a newline.
@@ -171,9 +298,9 @@ func (lw *lineWriter) Write(p []byte) (n int, err error) {
 	lw.Lock()
 	defer lw.Unlock()
 	if len(p) == 0 || p[len(p)-1] != '\n' {
-		return lw.w.Write(append(p, '\n'))
+		return lw.write(append(p, '\n'))
 	}
-	return lw.w.Write(p)
+	return lw.write(p)
 }
 
 // WriteString is the same as lw.Write but takes a string.
@@ -184,9 +311,9 @@ func (lw *lineWriter) WriteString(s string) (n int, err error) {
 		p := make([]byte, len(s)+1)
 		copy(p, s)
 		p[len(s)] = '\n'
-		return lw.w.Write(p)
+		return lw.write(p)
 	}
-	return io.WriteString(lw.w, s)
+	return lw.write([]byte(s))
 }
 
 var std = New(os.Stderr, color.IsTerminal(os.Stderr))
@@ -256,6 +383,16 @@ func SetOutput(w io.Writer) {
 	std.SetOutput(w)
 }
 
+// SetResetOnExit sets whether the standard Logger resets the attributes before exiting or panicking.
+func SetResetOnExit(reset bool) {
+	std.SetResetOnExit(reset)
+}
+
+// SetAutoReset sets whether the standard Logger resets the attributes left active by a format.
+func SetAutoReset(reset bool) {
+	std.SetAutoReset(reset)
+}
+
 // SetColor sets whether colored output is enabled for the standard Logger.
 func SetColor(color bool) {
 	std.SetColor(color)
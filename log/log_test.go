@@ -104,6 +104,28 @@ func TestPanicln(t *testing.T) {
 	panic("Impossible")
 }
 
+func TestPanicReset(t *testing.T) {
+	t.Parallel()
+	for _, reset := range []bool{true, false} {
+		var b bytes.Buffer
+		l := New(&b, true)
+		l.SetResetOnExit(reset)
+		exp := color.Run("%h[fgRed]foo\n", true)
+		if reset {
+			exp += color.Reset
+		}
+		func() {
+			defer func() {
+				recover()
+			}()
+			l.Panicf("%h[fgRed]foo")
+		}()
+		if b.String() != exp {
+			t.Errorf("Expected %q but result was %q", exp, b.String())
+		}
+	}
+}
+
 func BenchmarkPrintln(b *testing.B) {
 	l := New(ioutil.Discard, true)
 	for i := 0; i < b.N; i++ {
@@ -128,3 +150,51 @@ func BenchmarkZap(b *testing.B) {
 		}
 	})
 }
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	l := New(&b, true)
+	w := l.Writer()
+	fmt.Fprint(w, "foo %h[fgRed]")
+	if b.Len() != 0 {
+		t.Errorf("Expected partial line to be buffered but result was %q", b.String())
+	}
+	fmt.Fprint(w, "bar\nbaz\nqux")
+	exp := "foo %h[fgRed]bar\nbaz\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	fmt.Fprint(w, "\n")
+	exp += "qux\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestAutoReset(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	l := New(&b, true)
+	red := color.Run("%h[fgRed]", true)
+	l.Printf("%h[fgRed]error: %s\n", "foo")
+	l.Printfp(color.Prepare("%h[fgRed]error"))
+	l.Printf("plain")
+	exp := red + "error: foo" + color.Reset + "\n" + red + "error" + color.Reset + "\n" + "plain\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	l.SetAutoReset(false)
+	l.Printf("%h[fgRed]error")
+	if exp = red + "error\n"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	l.SetAutoReset(true)
+	l.SetColor(false)
+	l.Printf("%h[fgRed]error")
+	if exp = "error\n"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"log"
+	"runtime"
+	"strings"
+)
+
+// Wrap returns a Logger that processes the highlight verbs and then logs through l.
+// The output, prefix and flags of l are used for everything that is logged, including
+// any changes made to them later. The file and line number of the flags refer to the
+// caller of the Logger methods.
+// The color argument dictates whether color output is enabled.
+// The SetOutput method of the returned Logger replaces l as the destination.
+func Wrap(l *log.Logger, color bool) *Logger {
+	return New(stdWriter{l}, color)
+}
+
+// stdWriter writes to a *log.Logger.
+type stdWriter struct {
+	l *log.Logger
+}
+
+// Write logs p with w.l.
+func (w stdWriter) Write(p []byte) (n int, err error) {
+	return len(p), w.l.Output(callDepth(), string(p))
+}
+
+// pkgPrefix is the prefix of the names of the functions in this package.
+var pkgPrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	i := strings.LastIndex(name, "/")
+	return name[:i+strings.IndexByte(name[i:], '.')+1]
+}()
+
+// callDepth returns the calldepth argument for log.Logger.Output from stdWriter.Write
+// such that the file and line number refer to the first caller outside this package.
+func callDepth() int {
+	pc := make([]uintptr, 16)
+	// Skip runtime.Callers and callDepth.
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	// stdWriter.Write is at depth 1.
+	for depth := 1; ; depth++ {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) &&
+			!strings.HasPrefix(f.Function, "fmt.") &&
+			!strings.HasPrefix(f.Function, "io.") {
+			return depth
+		}
+		if !more {
+			return 1
+		}
+	}
+}
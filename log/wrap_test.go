@@ -0,0 +1,36 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/nhooyr/color"
+	clog "github.com/nhooyr/color/log"
+)
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	sl := log.New(&b, "pre: ", log.Lshortfile)
+	l := clog.Wrap(sl, true)
+	f := color.Prepare("%h[fgRed]foo%r %s")
+	_, _, line, _ := runtime.Caller(0)
+	l.Printf("%h[fgRed]foo%r %s", "bar")
+	exp := "pre: wrap_test.go:" + strconv.Itoa(line+1) + ": " + fmt.Sprintf(f.Get(true), "bar") + "\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	sl.SetPrefix("new: ")
+	sl.SetFlags(0)
+	l.SetColor(false)
+	l.Println("foo", "bar")
+	exp = "new: foo bar\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
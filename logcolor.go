@@ -0,0 +1,117 @@
+package color
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// logLevels holds the levels that a LogColorizer detects with their styles and their
+// default patterns.
+var logLevels = [...]struct {
+	level, name, spec, pattern string
+}{
+	{"error", "logError", "fgRed+bold", `(?i)\b(error|fatal)\b`},
+	{"warn", "logWarn", "fgYellow", `(?i)\bwarn(ing)?\b`},
+	{"info", "logInfo", "fgGreen", `(?i)\binfo\b`},
+	{"debug", "logDebug", "dim", `(?i)\bdebug\b`},
+}
+
+// LogColorizer is an io.Reader that colors the lines of logs read from another reader
+// by the level they are at, e.g. to follow the output of a program. See NewLogColorizer.
+type LogColorizer struct {
+	r         *bufio.Reader
+	color     bool
+	res       [len(logLevels)]*regexp.Regexp
+	starts    [len(logLevels)]string // processed styles of the levels
+	wholeLine bool
+	buf       []byte // colored bytes not yet read
+	err       error  // error from the last read from r
+}
+
+// NewLogColorizer returns a LogColorizer that reads from r and colors the keyword of the
+// level of each line: "error" or "fatal", "warn" or "warning", "info" and "debug", as whole
+// words in any case, e.g. "ERROR" in "2006-01-02 ERROR: failed". The keyword is written in
+// the style registered as logError, logWarn, logInfo or logDebug according to its level,
+// or red and bold, yellow, green and dim if they are not registered, and followed by a
+// reset. The level of a line is that of the first keyword in it, lines without any are
+// left plain. The styles are looked up when the LogColorizer is created.
+// The text is matched a line at a time and lines and runes split across reads from r are
+// matched correctly. The color argument dictates whether color output is enabled, if it
+// is not, the text is read unchanged.
+func NewLogColorizer(r io.Reader, color bool) *LogColorizer {
+	lc := &LogColorizer{r: bufio.NewReader(r), color: color && tiErr == nil}
+	for i, ll := range logLevels {
+		lc.res[i] = regexp.MustCompile(ll.pattern)
+		lc.starts[i] = themedStart(ll.name, ll.spec)
+	}
+	return lc
+}
+
+// SetPattern sets the pattern that detects level, which is "error", "warn", "info" or
+// "debug", to re instead of its keywords. A nil re never detects level.
+// It returns an error for any other level.
+func (lc *LogColorizer) SetPattern(level string, re *regexp.Regexp) error {
+	for i, ll := range logLevels {
+		if ll.level == level {
+			lc.res[i] = re
+			return nil
+		}
+	}
+	return fmt.Errorf("color: unknown log level %q", level)
+}
+
+// SetWholeLine sets whether the whole line is colored instead of only the match of the
+// pattern of its level. The line ending is left outside of the style.
+func (lc *LogColorizer) SetWholeLine(on bool) {
+	lc.wholeLine = on
+}
+
+// Read reads the next line from the underlying reader if necessary
+// and then reads from the colored line.
+func (lc *LogColorizer) Read(p []byte) (n int, err error) {
+	for len(lc.buf) == 0 {
+		if lc.err != nil {
+			return 0, lc.err
+		}
+		var line []byte
+		line, lc.err = lc.r.ReadBytes('\n')
+		lc.buf = lc.colorize(line)
+	}
+	n = copy(p, lc.buf)
+	lc.buf = lc.buf[n:]
+	return n, nil
+}
+
+// colorize returns line colored by its level.
+func (lc *LogColorizer) colorize(line []byte) []byte {
+	if !lc.color {
+		return line
+	}
+	level := -1
+	var m []int
+	for i, re := range lc.res {
+		if re == nil {
+			continue
+		}
+		if lm := re.FindIndex(line); lm != nil && lm[0] != lm[1] && (m == nil || lm[0] < m[0]) {
+			level, m = i, lm
+		}
+	}
+	if level == -1 {
+		return line
+	}
+	var b bytes.Buffer
+	if lc.wholeLine {
+		writeStyledLine(&b, lc.starts[level], string(line))
+		return b.Bytes()
+	}
+	b.Write(line[:m[0]])
+	b.WriteString(lc.starts[level])
+	b.Write(line[m[0]:m[1]])
+	b.WriteString(reset())
+	b.Write(line[m[1]:])
+	return b.Bytes()
+}
@@ -0,0 +1,63 @@
+package color
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewLogColorizer(t *testing.T) {
+	t.Parallel()
+	const s = "12:00 ERROR: failed\nINFO no error\nplain\r\n[warning] low disk\r\ndebug\ninformation"
+	er := Highlight("%h[fgRed+bold]")
+	wa := Highlight("%h[fgYellow]")
+	in := Highlight("%h[fgGreen]")
+	de := Highlight("%h[dim]")
+	r := reset()
+	exp := "12:00 " + er + "ERROR" + r + ": failed\n" + in + "INFO" + r + " no error\nplain\r\n[" +
+		wa + "warning" + r + "] low disk\r\n" + de + "debug" + r + "\ninformation"
+	// One byte at a time to split the lines and runes across reads.
+	lc := NewLogColorizer(iotest.OneByteReader(strings.NewReader(s)), true)
+	b, err := ioutil.ReadAll(iotest.OneByteReader(lc))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tiErr != nil {
+		exp = s
+	}
+	if string(b) != exp {
+		t.Errorf("Expected %q but result was %q", exp, b)
+	}
+
+	lc = NewLogColorizer(strings.NewReader(s), true)
+	lc.SetWholeLine(true)
+	if err := lc.SetPattern("info", regexp.MustCompile(`^INFO`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lc.SetPattern("debug", nil)
+	b, err = ioutil.ReadAll(lc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp = er + "12:00 ERROR: failed" + r + "\n" + in + "INFO no error" + r + "\nplain\r\n" +
+		wa + "[warning] low disk" + r + "\r\ndebug\ninformation"
+	if tiErr != nil {
+		exp = s
+	}
+	if string(b) != exp {
+		t.Errorf("Expected %q but result was %q", exp, b)
+	}
+	if err := lc.SetPattern("trace", nil); err == nil {
+		t.Errorf("Expected an error from an unknown level")
+	}
+
+	b, err = ioutil.ReadAll(NewLogColorizer(strings.NewReader(s), false))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(b) != s {
+		t.Errorf("Expected %q but result was %q", s, b)
+	}
+}
@@ -0,0 +1,88 @@
+package color
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RunMarkup returns the result of fmt.Sprintf with format and the arguments in a, except
+// that the highlight verbs in format are kept as they are instead of being processed.
+// Every other '%' in the result is escaped as "%%" so that the result is itself a format
+// without arguments: Printf(RunMarkup(format, a...)) prints the same as
+// Printf(format, a...), and Run, Strip and the like can render it later.
+// Each Format in a is expanded to its stripped string since its verbs are not kept.
+func RunMarkup(format string, a ...interface{}) string {
+	format = markupFormat(format)
+	raw := rawArgs(format, len(a))
+	args := make([]interface{}, len(a))
+	for i, v := range a {
+		if f, ok := v.(*Format); ok {
+			v = f.Get(false)
+		}
+		if raw[i] || plainArg(v) {
+			args[i] = v
+		} else {
+			args[i] = markupArg{v}
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// rawArgs returns which of the n arguments of format are used as a width or precision
+// or by %T or %p, which write no '%' and break if the argument is wrapped.
+func rawArgs(format string, n int) []bool {
+	raw := make([]bool, n)
+	argNum := 0
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		var ok bool
+		i, argNum, ok = scanDirective(format, i+1, argNum, func(arg int, verb rune) {
+			if arg >= 0 && arg < n && (verb == '*' || verb == 'T' || verb == 'p') {
+				raw[arg] = true
+			}
+		})
+		if !ok {
+			break
+		}
+	}
+	return raw
+}
+
+// markupFormat returns format with the highlight verbs and escaped percent signs
+// escaped again, so that fmt writes them as they are in format.
+func markupFormat(format string) string {
+	if strings.IndexByte(format, '%') == -1 {
+		return format
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(format); i++ {
+		buf.WriteByte(format[i])
+		if format[i] != '%' || i+1 == len(format) {
+			continue
+		}
+		switch format[i+1] {
+		case '%':
+			buf.WriteString("%%%")
+			i++
+		case 'h', 'r', '{', '}':
+			buf.WriteByte('%')
+		}
+	}
+	return buf.String()
+}
+
+// markupArg wraps a value so that the percent signs in it are escaped when formatted by fmt.
+type markupArg struct {
+	v interface{}
+}
+
+// Format formats m.v according to the verb and the flags in f and
+// then writes it to f with its percent signs escaped.
+func (m markupArg) Format(f fmt.State, verb rune) {
+	io.WriteString(f, strings.Replace(fmt.Sprintf(directive(f, verb), m.v), "%", "%%", -1))
+}
@@ -0,0 +1,42 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+var markups = []struct {
+	format string
+	a      []interface{}
+	exp    string
+}{
+	{"foo", nil, "foo"},
+	{"%h[fgRed]%s%r: %d%%", []interface{}{"foo", 5}, "%h[fgRed]foo%r: 5%%"},
+	{"%h[bold]%-5s|%q", []interface{}{"50%", "%h[x]"}, "%h[bold]50%%  |\"%%h[x]\""},
+	{"%[2]v %[1]v%h[fgBlue]", []interface{}{1, 2}, "2 1%h[fgBlue]"},
+	{"%v", []interface{}{Prepare("%h[fgRed]foo")}, "foo"},
+	{"%h[fgRed", nil, "%h[fgRed"},
+	{"%", nil, "%!(NOVERB)"},
+	{"%h[fgRed]%*d|%T|%-*s|%r", []interface{}{5, 42, "x%", 4, "a%"}, "%h[fgRed]   42|string|a%%  |%r"},
+}
+
+func TestRunMarkup(t *testing.T) {
+	t.Parallel()
+	for _, tc := range markups {
+		a := append([]interface{}(nil), tc.a...)
+		r := RunMarkup(tc.format, tc.a...)
+		if r != tc.exp {
+			t.Errorf("Expected %q from %q but result was %q", tc.exp, tc.format, r)
+		}
+		if tc.format == "%" || tc.format == "%h[fgRed" || tc.format == "%v" {
+			// Errors and Formats do not round trip.
+			continue
+		}
+		var b, b2 bytes.Buffer
+		New(&b, true).Printf(r)
+		New(&b2, true).Printf(tc.format, a...)
+		if b.String() != b2.String() {
+			t.Errorf("Expected %q from %q but result was %q", b2.String(), r, b.String())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package color
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+)
+
+// NewMatchHighlighter returns an io.Reader that reads from r and highlights every match
+// of re in the style of spec, like grep --color. The spec argument is the list of
+// attributes as it would appear between the brackets of the highlight verb, e.g.
+// "fgRed+bold". Each match is followed by a reset.
+// The text is matched a line at a time, so matches cannot span multiple lines but lines
+// and runes split across reads from r are matched correctly.
+// The color argument dictates whether color output is enabled, if it is not,
+// the text is read unchanged.
+func NewMatchHighlighter(r io.Reader, re *regexp.Regexp, spec string, color bool) io.Reader {
+	if !color || tiErr != nil {
+		return r
+	}
+	return &matchHighlighter{
+		r:     bufio.NewReader(r),
+		re:    re,
+		start: Highlight(verb(spec)),
+	}
+}
+
+// matchHighlighter highlights the matches of a regexp in the lines it reads.
+type matchHighlighter struct {
+	r     *bufio.Reader
+	re    *regexp.Regexp
+	start string // processed spec
+	buf   []byte // highlighted bytes not yet read
+	err   error  // error from the last read from r
+}
+
+// Read reads the next line from the underlying reader if necessary
+// and then reads from the highlighted line.
+func (mh *matchHighlighter) Read(p []byte) (n int, err error) {
+	for len(mh.buf) == 0 {
+		if mh.err != nil {
+			return 0, mh.err
+		}
+		var line []byte
+		line, mh.err = mh.r.ReadBytes('\n')
+		mh.buf = mh.highlight(line)
+	}
+	n = copy(p, mh.buf)
+	mh.buf = mh.buf[n:]
+	return n, nil
+}
+
+// highlight returns line with all matches highlighted.
+func (mh *matchHighlighter) highlight(line []byte) []byte {
+	matches := mh.re.FindAllIndex(line, -1)
+	if len(matches) == 0 {
+		return line
+	}
+	b := make([]byte, 0, len(line)+len(matches)*(len(mh.start)+len(reset())))
+	prev := 0
+	for _, m := range matches {
+		if m[0] == m[1] {
+			continue
+		}
+		b = append(b, line[prev:m[0]]...)
+		b = append(b, mh.start...)
+		b = append(b, line[m[0]:m[1]]...)
+		b = append(b, reset()...)
+		prev = m[1]
+	}
+	return append(b, line[prev:]...)
+}
@@ -0,0 +1,35 @@
+package color
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewMatchHighlighter(t *testing.T) {
+	t.Parallel()
+	const s = "foo bar\nbaz föo\nx*foo"
+	re := regexp.MustCompile(`f.o|x*`)
+	start := Highlight("%h[fgRed+bold]")
+	exp := start + "foo" + reset() + " bar\nbaz " + start + "föo" + reset() + "\n" +
+		start + "x" + reset() + "*" + start + "foo" + reset()
+	// One byte at a time to split the lines and runes across reads.
+	r := NewMatchHighlighter(iotest.OneByteReader(strings.NewReader(s)), re, "fgRed+bold", true)
+	b, err := ioutil.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(b) != exp {
+		t.Errorf("Expected %q but result was %q", exp, b)
+	}
+	r = NewMatchHighlighter(strings.NewReader(s), re, "fgRed+bold", false)
+	b, err = ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(b) != s {
+		t.Errorf("Expected %q but result was %q", s, b)
+	}
+}
@@ -0,0 +1,74 @@
+package color
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Minify merges consecutive SGR sequences in s into one and drops the attributes that
+// are immediately reset, e.g. "\x1b[31m\x1b[1m" becomes "\x1b[31;1m" and
+// "\x1b[1m\x1b[0m\x1b[31m" becomes "\x1b[0;31m". The output renders the same as s.
+func Minify(s string) string {
+	if strings.IndexByte(s, '\x1b') == -1 {
+		return s
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(s))
+	var params []string
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], '\x1b')
+		if j == -1 {
+			buf.WriteString(s[i:])
+			break
+		}
+		buf.WriteString(s[i : i+j])
+		i += j
+		start, count := i, 0
+		params = params[:0]
+		for {
+			p, n := sgrAt(s[i:])
+			if n == 0 {
+				break
+			}
+			if p == "" || p == "0" {
+				params = params[:0]
+				p = "0"
+			}
+			params = append(params, p)
+			i += n
+			count++
+		}
+		switch count {
+		case 0:
+			n := escapeLen(s[i:])
+			if n == 0 {
+				n = 1
+			}
+			buf.WriteString(s[i : i+n])
+			i += n
+		case 1:
+			buf.WriteString(s[start:i])
+		default:
+			buf.WriteString("\x1b[")
+			buf.WriteString(strings.Join(params, ";"))
+			buf.WriteByte('m')
+		}
+	}
+	return buf.String()
+}
+
+// sgrAt returns the parameters and the length of the SGR sequence at the start of s.
+// The length is 0 if s does not start with a SGR sequence.
+func sgrAt(s string) (params string, n int) {
+	n = escapeLen(s)
+	if n < 3 || s[1] != '[' || s[n-1] != 'm' {
+		return "", 0
+	}
+	params = s[2 : n-1]
+	for i := 0; i < len(params); i++ {
+		if ch := params[i]; ch != ';' && ch != ':' && (ch < '0' || ch > '9') {
+			return "", 0
+		}
+	}
+	return params, n
+}
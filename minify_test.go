@@ -0,0 +1,30 @@
+package color
+
+import "testing"
+
+var minified = map[string]string{
+	"":                                "",
+	"foo":                             "foo",
+	"\x1b[31m":                        "\x1b[31m",
+	"\x1b[m":                          "\x1b[m",
+	"\x1b[31m\x1b[1m":                 "\x1b[31;1m",
+	"\x1b[0m\x1b[31m":                 "\x1b[0;31m",
+	"\x1b[1m\x1b[0m\x1b[31m":          "\x1b[0;31m",
+	"\x1b[31m\x1b[m":                  "\x1b[0m",
+	"\x1b[38;5;2m\x1b[48:2::1:2:3m":   "\x1b[38;5;2;48:2::1:2:3m",
+	"\x1b[31mfoo\x1b[1m":              "\x1b[31mfoo\x1b[1m",
+	"\x1b[31m\x1b[K\x1b[1m\x1b[4mhi":  "\x1b[31m\x1b[K\x1b[1;4mhi",
+	"\x1b(B\x1b[m\x1b[1m":             "\x1b(B\x1b[0;1m",
+	"\x1b[?1m\x1b[1m":                 "\x1b[?1m\x1b[1m",
+	"\x1b\x1b[1m\x1b[4m\x1b":          "\x1b\x1b[1;4m\x1b",
+	"a\x1b[1m\x1b[0mb\x1b[32m\x1b[1m": "a\x1b[0mb\x1b[32;1m",
+}
+
+func TestMinify(t *testing.T) {
+	t.Parallel()
+	for k, v := range minified {
+		if r := Minify(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+}
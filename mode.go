@@ -0,0 +1,82 @@
+package color
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Mode is a choice of whether to enable color output, as in a --color flag.
+type Mode int
+
+// The color modes. The zero value is PerformCheck.
+const (
+	PerformCheck Mode = iota // enable color output if the writer supports it
+	EnableColor              // always enable color output
+	DisableColor             // never enable color output
+)
+
+// ParseMode returns the mode named by s, which is "auto", "always" or "never".
+// The aliases "true" and "1" mean "always" and "false" and "0" mean "never".
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "auto":
+		return PerformCheck, nil
+	case "always", "true", "1":
+		return EnableColor, nil
+	case "never", "false", "0":
+		return DisableColor, nil
+	}
+	return 0, fmt.Errorf("color: unknown mode %q, must be auto, always or never", s)
+}
+
+// String returns the name of m that ParseMode accepts.
+func (m Mode) String() string {
+	switch m {
+	case PerformCheck:
+		return "auto"
+	case EnableColor:
+		return "always"
+	case DisableColor:
+		return "never"
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Set sets m to the mode named by s as parsed by ParseMode.
+// With String, it implements flag.Value so a Mode can be used as a command line flag.
+func (m *Mode) Set(s string) error {
+	mode, err := ParseMode(s)
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// Color returns whether color output to out should be enabled under m, for example
+// New(os.Stdout, mode.Color(os.Stdout)).
+// With PerformCheck, it is enabled only if out is an *os.File for which SupportsColor
+// returns true.
+func (m Mode) Color(out io.Writer) bool {
+	switch m {
+	case EnableColor:
+		return true
+	case PerformCheck:
+		f, ok := out.(*os.File)
+		return ok && SupportsColor(f)
+	}
+	return false
+}
+
+// Level is the range of colors a terminal supports, see Printer.PrintfLevel.
+type Level int
+
+// The color levels, from no colors at all to true colors.
+const (
+	LevelNone      Level = iota // no colors, nor any other attributes
+	LevelBasic                  // the 16 named colors
+	Level256                    // the 256 colors
+	LevelTrueColor              // true colors
+)
@@ -0,0 +1,59 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+var parsedModes = map[string]Mode{
+	"auto":   PerformCheck,
+	"always": EnableColor,
+	"true":   EnableColor,
+	"1":      EnableColor,
+	"never":  DisableColor,
+	"false":  DisableColor,
+	"0":      DisableColor,
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+	for k, v := range parsedModes {
+		m, err := ParseMode(k)
+		if err != nil {
+			t.Errorf("Unexpected error from %q: %v", k, err)
+		}
+		if m != v {
+			t.Errorf("Expected %v from %q but result was %v", v, k, m)
+		}
+		if m2, _ := ParseMode(m.String()); m2 != m {
+			t.Errorf("Expected %v from %q but result was %v", m, m.String(), m2)
+		}
+	}
+	for _, s := range []string{"", "Always", "yes", "2"} {
+		if _, err := ParseMode(s); err == nil {
+			t.Errorf("Expected error from %q", s)
+		}
+	}
+	if s := Mode(7).String(); s != "Mode(7)" {
+		t.Errorf("Expected %q but result was %q", "Mode(7)", s)
+	}
+}
+
+func TestModeColor(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if !EnableColor.Color(&b) || DisableColor.Color(&b) || PerformCheck.Color(&b) {
+		t.Errorf("Unexpected color preference for a buffer")
+	}
+}
+
+func TestModeSet(t *testing.T) {
+	t.Parallel()
+	m := EnableColor
+	if err := m.Set("never"); err != nil || m != DisableColor {
+		t.Errorf("Expected %v but result was %v, %v", DisableColor, m, err)
+	}
+	if err := m.Set("sometimes"); err == nil || m != DisableColor {
+		t.Errorf("Expected an error and %v but result was %v, %v", DisableColor, m, err)
+	}
+}
@@ -0,0 +1,63 @@
+package color
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Overlay returns s, which contains escape sequences rather than highlight verbs, with
+// the columns from start up to but not including end highlighted in the style of spec,
+// e.g. "reverse" to highlight a search hit. The spec argument is the list of attributes
+// as it would appear between the brackets of the highlight verb. The style is layered
+// over the style of the text: it is set again after every SGR sequence in the overlaid
+// columns and at end, a reset is followed by the SGR sequences that were active in s
+// so that the rest of s keeps its style. Columns are counted as in WrapText: escape
+// sequences occupy no columns and wide runes occupy two. s is returned unchanged if
+// spec is invalid or the columns are empty.
+func Overlay(s string, start, end int, spec string) string {
+	if start >= end || specError(spec) != "" {
+		return s
+	}
+	ov := Highlight(verb(spec))
+	var (
+		buf    bytes.Buffer
+		active sgrState // SGR state of s at the current position
+		col    int
+		in     bool // inside the overlay
+		cw     clusterWidth
+	)
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if n := escapeLen(s[i:]); n > 0 {
+				e := s[i : i+n]
+				buf.WriteString(e)
+				if _, sn := sgrAt(e); sn > 0 {
+					active.track(e)
+					if in {
+						buf.WriteString(ov)
+					}
+				}
+				i += n
+				continue
+			}
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		rw := cw.width(r)
+		if rw > 0 {
+			if !in && col >= start && col < end {
+				buf.WriteString(ov)
+				in = true
+			} else if in && col >= end {
+				buf.WriteString(Reset + active.sgr())
+				in = false
+			}
+		}
+		buf.WriteString(s[i : i+n])
+		col += rw
+		i += n
+	}
+	if in {
+		buf.WriteString(Reset + active.sgr())
+	}
+	return buf.String()
+}
@@ -0,0 +1,31 @@
+package color
+
+import "testing"
+
+var overlays = []struct {
+	s          string
+	start, end int
+	spec       string
+	exp        string
+}{
+	{"foo bar baz", 4, 7, "reverse", "foo \x1b[7mbar" + Reset + " baz"},
+	{"\x1b[31mfoo bar\x1b[0m", 0, 3, "reverse", "\x1b[31m\x1b[7mfoo" + Reset + "\x1b[31m bar\x1b[0m"},
+	{"\x1b[31mfo\x1b[1mo bar", 1, 5, "reverse", "\x1b[31mf\x1b[7mo\x1b[1m\x1b[7mo b" + Reset + "\x1b[1;31mar"},
+	{"foo\x1b[0m bar", 2, 6, "reverse", "fo\x1b[7mo\x1b[0m\x1b[7m ba" + Reset + "r"},
+	{"a世b", 1, 3, "reverse", "a\x1b[7m世" + Reset + "b"},
+	{"foo", 1, 10, "bold", "f\x1b[1moo" + Reset},
+	{"foo", 2, 2, "bold", "foo"},
+	{"foo", 0, 2, "bolder", "foo"},
+}
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+	if tiErr != nil {
+		t.Skip("no terminfo")
+	}
+	for _, tc := range overlays {
+		if r := Overlay(tc.s, tc.start, tc.end, tc.spec); r != tc.exp {
+			t.Errorf("Expected %q from %q but result was %q", tc.exp, tc.s, r)
+		}
+	}
+}
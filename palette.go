@@ -0,0 +1,103 @@
+package color
+
+import "math"
+
+// RGB is a color as its red, green and blue components.
+type RGB struct {
+	R, G, B uint8
+}
+
+// basicRGBs holds the colors of the first 16 indexes of the xterm palette.
+var basicRGBs = [16]RGB{
+	{0, 0, 0},
+	{205, 0, 0},
+	{0, 205, 0},
+	{205, 205, 0},
+	{0, 0, 238},
+	{205, 0, 205},
+	{0, 205, 205},
+	{229, 229, 229},
+	{127, 127, 127},
+	{255, 0, 0},
+	{0, 255, 0},
+	{255, 255, 0},
+	{92, 92, 255},
+	{255, 0, 255},
+	{0, 255, 255},
+	{255, 255, 255},
+}
+
+// cubeLevels holds the values of the components in the 6x6x6 color cube of the xterm palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// RGBForIndex returns the color at index n in the xterm 256 color palette.
+func RGBForIndex(n uint8) RGB {
+	switch {
+	case n < 16:
+		return basicRGBs[n]
+	case n < 232:
+		n -= 16
+		return RGB{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	}
+	v := 8 + 10*(n-232)
+	return RGB{v, v, v}
+}
+
+// ColorByName returns the palette index and the color of the named color name as it
+// appears in the named color attributes, e.g. "BrightRed" for %h[fgBrightRed].
+// It returns false if there is no such named color. Default is not a named color
+// in this sense, its index and color are up to the terminal.
+func ColorByName(name string) (index uint8, rgb RGB, ok bool) {
+	for i, n := range colorNames {
+		if n == name {
+			return uint8(i), RGBForIndex(uint8(i)), true
+		}
+	}
+	return 0, RGB{}, false
+}
+
+// NameForIndex returns the name of the named color at index in the palette, the inverse
+// of ColorByName. It returns false if index is not one of the first 16 indexes, which
+// are the only named ones.
+func NameForIndex(index uint8) (string, bool) {
+	if int(index) >= len(colorNames) {
+		return "", false
+	}
+	return colorNames[index], true
+}
+
+// ColorDistance returns the perceptual distance between a and b.
+// It uses the "redmean" approximation of the Euclidean distance which weights
+// the components according to how red the colors are. It is much cheaper
+// than converting to a perceptual color space while being close enough to rank
+// colors by distance.
+func ColorDistance(a, b RGB) float64 {
+	rmean := (float64(a.R) + float64(b.R)) / 2
+	dr := float64(a.R) - float64(b.R)
+	dg := float64(a.G) - float64(b.G)
+	db := float64(a.B) - float64(b.B)
+	return math.Sqrt((2+rmean/256)*dr*dr + 4*dg*dg + (2+(255-rmean)/256)*db*db)
+}
+
+// NearestPaletteIndex returns the index of the color in the xterm 256 color palette
+// nearest to c according to ColorDistance.
+func NearestPaletteIndex(c RGB) uint8 {
+	return uint8(nearest(c, 256))
+}
+
+// nearest16 returns the index of the color among the first 16 palette colors nearest to c.
+func nearest16(c RGB) int {
+	return nearest(c, 16)
+}
+
+// nearest returns the index of the color among the first n palette colors nearest to c.
+// The lowest index wins a tie.
+func nearest(c RGB, n int) int {
+	best, bestDist := 0, math.Inf(1)
+	for i := 0; i < n; i++ {
+		if d := ColorDistance(c, RGBForIndex(uint8(i))); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,116 @@
+package color
+
+import "testing"
+
+var paletteRGBs = map[uint8]RGB{
+	1:   {205, 0, 0},
+	15:  {255, 255, 255},
+	16:  {0, 0, 0},
+	196: {255, 0, 0},
+	21:  {0, 0, 255},
+	231: {255, 255, 255},
+	110: {135, 175, 215},
+	232: {8, 8, 8},
+	255: {238, 238, 238},
+}
+
+func TestRGBForIndex(t *testing.T) {
+	t.Parallel()
+	for k, v := range paletteRGBs {
+		if r := RGBForIndex(k); r != v {
+			t.Errorf("Expected %v from %d but result was %v", v, k, r)
+		}
+	}
+}
+
+func TestColorNames(t *testing.T) {
+	t.Parallel()
+	for i := 0; i < 16; i++ {
+		name, ok := NameForIndex(uint8(i))
+		if !ok {
+			t.Fatalf("Expected a name for %d", i)
+		}
+		index, rgb, ok := ColorByName(name)
+		if !ok || index != uint8(i) {
+			t.Errorf("Expected %d from %q but result was %d", i, name, index)
+		}
+		if rgb != RGBForIndex(index) {
+			t.Errorf("Expected %v from %q but result was %v", RGBForIndex(index), name, rgb)
+		}
+		if c, ok := colors[name]; !ok || c != i {
+			t.Errorf("Expected %d from the attribute %q but result was %d", i, name, c)
+		}
+	}
+	if index, rgb, _ := ColorByName("BrightBlue"); index != 12 || rgb != (RGB{92, 92, 255}) {
+		t.Errorf("Expected %d and %v but result was %d and %v", 12, RGB{92, 92, 255}, index, rgb)
+	}
+	if _, _, ok := ColorByName("Default"); ok {
+		t.Errorf("Expected no color from %q", "Default")
+	}
+	if _, ok := NameForIndex(16); ok {
+		t.Errorf("Expected no name for %d", 16)
+	}
+}
+
+var nearest16s = map[RGB]int{
+	{255, 0, 0}:     9,
+	{200, 10, 10}:   1,
+	{0, 0, 0}:       0,
+	{10, 10, 10}:    0,
+	{250, 250, 250}: 15,
+	{128, 128, 128}: 8,
+	{0, 0, 240}:     4,
+}
+
+func TestNearest16(t *testing.T) {
+	t.Parallel()
+	for k, v := range nearest16s {
+		if r := nearest16(k); r != v {
+			t.Errorf("Expected %d from %v but result was %d", v, k, r)
+		}
+	}
+}
+
+var distanceOrders = []struct {
+	c, near, far RGB
+}{
+	{RGB{255, 0, 0}, RGB{250, 10, 10}, RGB{0, 0, 255}},
+	{RGB{0, 0, 0}, RGB{30, 30, 30}, RGB{255, 255, 255}},
+	{RGB{0, 128, 0}, RGB{0, 100, 0}, RGB{128, 128, 0}},
+	// Green differences matter more than blue ones.
+	{RGB{100, 100, 100}, RGB{100, 100, 140}, RGB{100, 140, 100}},
+}
+
+func TestColorDistance(t *testing.T) {
+	t.Parallel()
+	for _, tc := range distanceOrders {
+		near, far := ColorDistance(tc.c, tc.near), ColorDistance(tc.c, tc.far)
+		if near >= far {
+			t.Errorf("Expected %v to be nearer %v than %v but the distances were %v and %v", tc.near, tc.c, tc.far, near, far)
+		}
+	}
+	if d := ColorDistance(RGB{1, 2, 3}, RGB{1, 2, 3}); d != 0 {
+		t.Errorf("Expected 0 but result was %v", d)
+	}
+	if d, d2 := ColorDistance(RGB{1, 2, 3}, RGB{40, 50, 60}), ColorDistance(RGB{40, 50, 60}, RGB{1, 2, 3}); d != d2 {
+		t.Errorf("Expected %v but result was %v", d, d2)
+	}
+}
+
+var nearestPaletteIndexes = map[RGB]uint8{
+	{255, 0, 0}:     9,
+	{0, 0, 0}:       0,
+	{135, 175, 215}: 110,
+	{130, 170, 220}: 110,
+	{48, 48, 48}:    236,
+	{255, 135, 0}:   208,
+}
+
+func TestNearestPaletteIndex(t *testing.T) {
+	t.Parallel()
+	for k, v := range nearestPaletteIndexes {
+		if r := NearestPaletteIndex(k); r != v {
+			t.Errorf("Expected %d from %v but result was %d", v, k, r)
+		}
+	}
+}
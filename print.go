@@ -1,23 +1,114 @@
 package color
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 
-	"golang.org/x/crypto/ssh/terminal"
+	"github.com/nhooyr/terminfo/caps"
 )
 
 // Printer prints to a writer using highlight verbs.
 type Printer struct {
 	out   io.Writer // underlying writer
 	color bool      // enable color output
+
+	mu        sync.Mutex
+	base      string // processed base style
+	flags     int    // properties of the output
+	verbosity int    // highest level printed by Vprintf
+
+	indent      string        // processed indent written at the start of each line
+	indentWidth int           // columns occupied by indent
+	linePrefix  func() string // returns the prefix written at the start of each line
+	midLine     bool          // the output does not end at the start of a line
+	active      sgrState      // SGR state at the end of the output when prefixing
 }
 
+// These flags change the output of a Printer.
+// Combine them with | and set them with SetFlags.
+const (
+	// SanitizeArgs removes control characters and escape sequences from the
+	// arguments. See Sanitize for exactly what is removed, except that an ESC that
+	// does not start a complete escape sequence, e.g. in a truncated "\x1b[31", is
+	// replaced with "^[" so that what was left of the sequence is visible.
+	SanitizeArgs = 1 << iota
+
+	// AutoResetEnd appends a reset to the output of Printf, Printfp and PrintfWidth
+	// if the format leaves any attributes active. It has no effect on Formats in
+	// the arguments. A Printer with a base style always appends a reset.
+	AutoResetEnd
+
+	// Force16 maps 256 and true colors in the format of Printf and PrintfWidth to their
+	// nearest color among the 16 named colors so that only the basic SGR color codes
+	// are written. Underline colors are mapped too but written as 58;5;n, as there is no
+	// basic code for them. It has no effect on Formats, which are processed by Prepare.
+	Force16
+
+	// MinifyOutput passes all output through Minify before writing it.
+	MinifyOutput
+
+	// PassthroughUnknown makes a highlight verb with an unknown attribute in the format
+	// of Printf and PrintfWidth print as is, e.g. %h[foo] prints "%h[foo]", and the rest
+	// of the format is processed as usual. Without it, the verb is replaced with
+	// %!h(BADATTR) and the rest of the format is dropped. Other errors in the verbs are
+	// not affected.
+	PassthroughUnknown
+
+	// IsolateArgs protects the attributes set by the format from resets in the arguments.
+	// Every reset in an argument that is formatted while attributes are active is followed
+	// by the sequences that set them again, e.g. the reset in the argument of
+	// Printf("%h[fgRed]%s bar%r", "\x1b[1mfoo\x1b[0m") does not stop "bar" from being red.
+	// Arguments used as the width or precision of a directive or by %T or %p, or used by
+	// several directives with different attributes active, are left alone.
+	IsolateArgs
+
+	// ColonSubparams writes the parameters of 256 and true colors, including underline
+	// colors, in the format of Printf and PrintfWidth separated by colons instead of
+	// semicolons, e.g. "\x1b[38:2::255:0:0m" instead of "\x1b[38;2;255;0;0m", for
+	// terminals that only parse the colon form of ITU-T T.416. The parameters of separate
+	// attributes are still separated by semicolons. It has no effect on Formats.
+	ColonSubparams
+
+	// Force256 maps true colors, including underline colors, in the format of Printf and
+	// PrintfWidth to their nearest color among the 256 colors. Force16 takes precedence
+	// over it. It has no effect on Formats.
+	Force256
+)
+
 // New creates a new Printer that writes to out.
 // The color argument dictates whether color output is enabled.
 func New(out io.Writer, color bool) *Printer {
-	return &Printer{out, color}
+	return &Printer{out: out, color: color}
+}
+
+// ColorEnabled returns true if p writes colored output. It is decided by the color
+// argument of New, or the mode given to a constructor such as NewBufferedPrinter,
+// and requires the terminfo entry of the terminal to be loaded. It does not change
+// over the lifetime of p. The print methods of p use it to decide whether to write
+// the escape sequences.
+func (p *Printer) ColorEnabled() bool {
+	return p.color && tiErr == nil
+}
+
+// Clone returns a new Printer with the same writer, color output, base style, flags,
+// indent and line prefix as p. Changing the settings of either Printer afterwards does not affect the other.
+func (p *Printer) Clone() *Printer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &Printer{
+		out:         p.out,
+		color:       p.color,
+		base:        p.base,
+		flags:       p.flags,
+		verbosity:   p.verbosity,
+		indent:      p.indent,
+		indentWidth: p.indentWidth,
+		linePrefix:  p.linePrefix,
+	}
 }
 
 // Printf first processes the highlight verbs in format and then calls
@@ -25,53 +116,424 @@ func New(out io.Writer, color bool) *Printer {
 // It will expand each Format in a to its appropriate string before calling fmt.Fprintf.
 // It returns the number of bytes written an any write error encountered.
 func (p *Printer) Printf(format string, a ...interface{}) (n int, err error) {
-	ExpandFormats(p.color, a)
-	return fmt.Fprintf(p.out, Run(format, p.color), a...)
+	format, flags := p.format(format, a)
+	return p.fprintf(flags, format, a...)
+}
+
+// PrintfStyle is the same as p.Printf but the output is printed in the style s, followed
+// by a reset unless the output already ends with one. The style is set after the base
+// style, so it takes precedence over it, and a reset in the output reverts to the base
+// style rather than s, as it does for the attributes of the highlight verbs.
+// The colors of s are written as the flags of p require, e.g. mapped to the nearest
+// named color with Force16. Nothing is added if color output is disabled or s is the
+// default style.
+func (p *Printer) PrintfStyle(s Style, format string, a ...interface{}) (n int, err error) {
+	spec := s.String()
+	if spec != "" {
+		format = verb(spec) + format
+	}
+	format, flags := p.format(format, a)
+	if spec != "" && p.ColorEnabled() && !strings.HasSuffix(format, reset()) {
+		format += reset()
+	}
+	return p.fprintf(flags, format, a...)
+}
+
+// PrintfLevel is the same as p.Printf but the colors in format that exceed level are
+// mapped to their nearest color at level for this call only, as if by the Force256 or
+// Force16 flag, whatever the flags of p. LevelTrueColor maps no colors and LevelNone
+// disables color output, including the base style, so the output is plain. Like the
+// flags, it has no effect on Formats in a. It is meant for printing the same output
+// to writers that support different colors.
+func (p *Printer) PrintfLevel(level Level, format string, a ...interface{}) (n int, err error) {
+	color := p.color && level > LevelNone
+	base, flags := p.prepareColor(a, color)
+	flags &^= Force16 | Force256
+	switch level {
+	case LevelBasic:
+		flags |= Force16
+	case Level256:
+		flags |= Force256
+	}
+	return p.fprintf(flags, p.formatWith(format, a, color, base, flags), a...)
+}
+
+// The sequences that begin and end synchronized output, during which the terminal
+// holds off on rendering.
+const (
+	beginSync = "\x1b[?2026h"
+	endSync   = "\x1b[?2026l"
+)
+
+// Synchronized calls f with a Printer that has the settings of p but writes to a buffer
+// and then writes everything that was printed to the writer of p at once, between the
+// sequences that begin and end synchronized output if color output is enabled. Terminals
+// that support synchronized output render it at once, which avoids flicker when redrawing
+// multiple parts of the screen, the rest ignore the sequences.
+// The line prefix and the indent of p are written when the output is, with p locked,
+// so they continue from the output of p before the call.
+// The output is written even if f returns an error, which is then returned.
+func (p *Printer) Synchronized(f func(*Printer) error) error {
+	var buf bytes.Buffer
+	bp := p.Clone()
+	bp.out = &buf
+	bp.indent, bp.indentWidth, bp.linePrefix = "", 0, nil
+	ferr := f(bp)
+	if buf.Len() == 0 {
+		return ferr
+	}
+	s := buf.String()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.prefixed() {
+		s = p.prefixLines(s)
+	}
+	if p.ColorEnabled() {
+		s = beginSync + s + endSync
+	}
+	_, err := io.WriteString(p.out, s)
+	if ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+// Vprintf is the same as p.Printf but it only prints if the verbosity of p is at least
+// level. Otherwise it returns immediately without processing format.
+func (p *Printer) Vprintf(level int, format string, a ...interface{}) (n int, err error) {
+	p.mu.Lock()
+	v := p.verbosity
+	p.mu.Unlock()
+	if v < level {
+		return 0, nil
+	}
+	return p.Printf(format, a...)
+}
+
+// PrintfWidth is the same as p.Printf but it returns the number of columns the output
+// occupies on a terminal instead of the number of bytes written. Escape sequences and
+// control characters occupy no columns and wide runes, e.g. CJK characters, occupy two.
+// It is meant for output that fits on a single line such as a progress bar that is
+// overwritten later.
+func (p *Printer) PrintfWidth(format string, a ...interface{}) (width int, err error) {
+	format, flags := p.format(format, a)
+	s, _, err := p.output(flags, fmt.Sprintf(format, a...))
+	return VisibleWidth(s), err
+}
+
+// format processes the highlight verbs in format for p and returns it with the flags
+// to print with. It applies the flags of p to a and expands each Format in a.
+func (p *Printer) format(format string, a []interface{}) (string, int) {
+	base, flags := p.prepare(a)
+	return p.formatWith(format, a, p.color, base, flags), flags
+}
+
+// formatWith processes the highlight verbs in format with color output enabled according
+// to color, the processed base style base and flags. It wraps the arguments in a as
+// required by flags.
+func (p *Printer) formatWith(format string, a []interface{}, color bool, base string, flags int) string {
+	if base != "" {
+		format, _ = process(format, true, base, flags)
+		format = base + format + reset()
+	} else {
+		var active bool
+		format, active = process(format, color, "", flags)
+		if active && flags&AutoResetEnd != 0 {
+			format += reset()
+		}
+	}
+	if flags&SanitizeArgs != 0 {
+		unsanitizeRawArgs(format, a)
+	}
+	if color {
+		p.isolate(flags, format, a)
+	}
+	return format
+}
+
+// isolate wraps the arguments in a as required by the IsolateArgs flag.
+func (p *Printer) isolate(flags int, format string, a []interface{}) {
+	if flags&IsolateArgs != 0 && p.ColorEnabled() {
+		isolateArgs(format, a)
+	}
+}
+
+// fprintf calls fmt.Fprintf to print to the underlying writer unless flags
+// require the output to be processed first.
+func (p *Printer) fprintf(flags int, format string, a ...interface{}) (n int, err error) {
+	if p.direct(flags) {
+		return fmt.Fprintf(p.out, format, a...)
+	}
+	return p.write(flags, fmt.Sprintf(format, a...))
+}
+
+// write writes s to the underlying writer after processing it according to flags.
+func (p *Printer) write(flags int, s string) (n int, err error) {
+	_, n, err = p.output(flags, s)
+	return n, err
+}
+
+// output processes s according to flags and the indent of p, writes it to the underlying
+// writer and returns what was written. If the lines are prefixed, p stays locked until
+// the write returns so that concurrent output is prefixed in the order it is written.
+func (p *Printer) output(flags int, s string) (out string, n int, err error) {
+	if flags&MinifyOutput != 0 {
+		s = Minify(s)
+	}
+	p.mu.Lock()
+	if !p.prefixed() {
+		p.mu.Unlock()
+		n, err = io.WriteString(p.out, s)
+		return s, n, err
+	}
+	defer p.mu.Unlock()
+	s = p.prefixLines(s)
+	n, err = io.WriteString(p.out, s)
+	return s, n, err
 }
 
 // Printfp is the same as p.Printf but takes a prepared format struct.
 func (p *Printer) Printfp(f *Format, a ...interface{}) (n int, err error) {
-	ExpandFormats(p.color, a)
-	return fmt.Fprintf(p.out, f.Get(p.color), a...)
+	base, flags := p.prepare(a)
+	var format string
+	if base == "" {
+		format = f.Get(p.color)
+		if f.active && p.color && flags&AutoResetEnd != 0 {
+			format += reset()
+		}
+	} else {
+		format = base + strings.Replace(f.Get(true), reset(), reset()+base, -1) + reset()
+	}
+	if flags&SanitizeArgs != 0 {
+		unsanitizeRawArgs(format, a)
+	}
+	p.isolate(flags, format, a)
+	return p.fprintf(flags, format, a...)
 }
 
 // Print calls fmt.Fprint to print to the underlying writer.
 // It will expand each Format in a to its appropriate string before calling fmt.Fprint.
 func (p *Printer) Print(a ...interface{}) (n int, err error) {
-	ExpandFormats(p.color, a)
-	return fmt.Fprint(p.out, a...)
+	base, flags := p.prepare(a)
+	if base == "" && p.direct(flags) {
+		return fmt.Fprint(p.out, a...)
+	}
+	s := fmt.Sprint(a...)
+	if base != "" {
+		s = base + s + reset()
+	}
+	return p.write(flags, s)
 }
 
 // Println calls fmt.Fprintln to print to the underlying writer.
 // It will expand each Format in a to its appropriate string before calling fmt.Fprintln.
 func (p *Printer) Println(a ...interface{}) (n int, err error) {
-	ExpandFormats(p.color, a)
-	return fmt.Fprintln(p.out, a...)
+	base, flags := p.prepare(a)
+	if base == "" && p.direct(flags) {
+		return fmt.Fprintln(p.out, a...)
+	}
+	s := fmt.Sprintln(a...)
+	if base != "" {
+		s = base + s + reset()
+	}
+	return p.write(flags, s)
+}
+
+// WriteRaw writes b, which may already contain escape sequences such as the output of Run
+// or of another program, without processing it as a format: a '%' in b is written as is
+// and fmt is not involved. If color output is disabled, the escape sequences in b are
+// removed instead. Otherwise b is printed in the base style like the output of Print,
+// with the base style set again after every reset in b. The other flags apply as usual,
+// except that AutoResetEnd does not, b is written as it is. It returns the number of
+// bytes written and any write error encountered.
+func (p *Printer) WriteRaw(b []byte) (n int, err error) {
+	s := string(b)
+	if !p.ColorEnabled() {
+		s = stripEscapes(s)
+	}
+	p.mu.Lock()
+	base, flags := p.base, p.flags
+	p.mu.Unlock()
+	if base != "" && p.ColorEnabled() {
+		s = base + reapply(s, base) + reset()
+	}
+	return p.write(flags, s)
+}
+
+// SetBaseStyle sets the style that all output of p is printed in.
+// The spec argument is the list of attributes as it would appear between the brackets
+// of the highlight verb, e.g. "fg245+dim". Every reset in the output, including %r,
+// reverts to the base style instead of the terminal default and a reset is written
+// after the output. An empty spec removes the base style.
+// The base style is ignored when color output is disabled.
+// It returns an error and leaves the base style unchanged if spec is invalid.
+func (p *Printer) SetBaseStyle(spec string) error {
+	var base string
+	if spec != "" {
+		if err := checkSpec(spec); err != nil {
+			return err
+		}
+		base = Highlight(verb(spec))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.base = base
+	return nil
+}
+
+// SetFlags sets the output flags of p.
+func (p *Printer) SetFlags(flag int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags = flag
+}
+
+// Flags returns the output flags of p.
+func (p *Printer) Flags() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.flags
+}
+
+// SetVerbosity sets the verbosity of p, which determines what Vprintf prints.
+// The verbosity of a new Printer is 0.
+func (p *Printer) SetVerbosity(v int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.verbosity = v
+}
+
+// prepare applies the flags of p to a, expands each Format in a and then
+// returns the processed base style and the flags to print with.
+func (p *Printer) prepare(a []interface{}) (base string, flags int) {
+	return p.prepareColor(a, p.color)
+}
+
+// prepareColor is the same as p.prepare but with color output enabled according to color
+// instead of the color argument of New.
+func (p *Printer) prepareColor(a []interface{}, color bool) (base string, flags int) {
+	p.mu.Lock()
+	base, flags = p.base, p.flags
+	p.mu.Unlock()
+	if flags&SanitizeArgs != 0 {
+		sanitizeArgs(a)
+	}
+	ExpandFormats(color, a)
+	if !color || tiErr != nil {
+		return "", flags
+	}
+	return base, flags
+}
+
+// reset returns the sequence to reset all attributes.
+func reset() string {
+	return ti.Strings[caps.ExitAttributeMode]
 }
 
 // IsTerminal returns true if f is a terminal and false otherwise.
+// On Windows, the pseudo terminals of MSYS2 and Cygwin count as terminals.
 func IsTerminal(f *os.File) bool {
-	return terminal.IsTerminal(int(f.Fd()))
+	return isTerminal(f)
+}
+
+var (
+	stdMu   sync.RWMutex
+	std     = New(os.Stdout, IsTerminal(os.Stdout))
+	stdMode = PerformCheck
+)
+
+// SetDefaultOutput replaces the default Printer, which the package level print functions
+// use, with a new Printer that writes to w with color output enabled according to mode.
+// The default Printer writes to standard output with PerformCheck until it is replaced.
+// It is meant for capturing output in tests, along with DefaultOutput to restore it:
+//
+//	w, mode := color.DefaultOutput()
+//	defer color.SetDefaultOutput(w, mode)
+//	var b bytes.Buffer
+//	color.SetDefaultOutput(&b, color.EnableColor)
+//
+// CaptureDefault does the same for the duration of a function.
+// It is safe to call SetDefaultOutput concurrently.
+func SetDefaultOutput(w io.Writer, mode Mode) {
+	p := New(w, mode.Color(w))
+	stdMu.Lock()
+	defer stdMu.Unlock()
+	std, stdMode = p, mode
+}
+
+// DefaultOutput returns the writer and the mode of the default Printer.
+func DefaultOutput() (io.Writer, Mode) {
+	stdMu.RLock()
+	defer stdMu.RUnlock()
+	return std.out, stdMode
 }
 
-var std = New(os.Stdout, IsTerminal(os.Stdout))
+// captureMu serializes the calls to CaptureDefault.
+var captureMu sync.Mutex
 
-// Printf calls the standard output Printer's Printf method.
+// CaptureDefault calls f and returns what the package level print functions printed
+// during the call, exactly as the default Printer would have printed it, colored or not.
+// The output is captured instead of being written to the writer of the default Printer,
+// which is restored when f returns or panics.
+// The default Printer is global, so the capture is too: it includes the output of every
+// goroutine that prints during the call and the calls to CaptureDefault from different
+// goroutines, e.g. parallel tests, are serialized so that they do not clobber each
+// other. Thus, f must not call CaptureDefault itself or wait for another goroutine to.
+// SetDefaultOutput must not be called during the call either.
+func CaptureDefault(f func()) string {
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	stdMu.Lock()
+	prev := std
+	p := prev.Clone()
+	p.out = nil
+	r := NewRecorder(p)
+	std = r.Printer()
+	stdMu.Unlock()
+	defer func() {
+		stdMu.Lock()
+		defer stdMu.Unlock()
+		std = prev
+	}()
+	f()
+	return strings.Join(r.Frames(), "")
+}
+
+// stdPrinter returns the default Printer.
+func stdPrinter() *Printer {
+	stdMu.RLock()
+	defer stdMu.RUnlock()
+	return std
+}
+
+// Printf calls the default Printer's Printf method.
 func Printf(format string, a ...interface{}) (n int, err error) {
-	return std.Printf(format, a...)
+	return stdPrinter().Printf(format, a...)
+}
+
+// FprintfMode is the same as Printf but prints to w with color output enabled according
+// to mode instead of the default Printer, as if by a Printer created for w with mode.
+// It is meant for one-off writes, create a Printer to print to w repeatedly.
+func FprintfMode(w io.Writer, mode Mode, format string, a ...interface{}) (n int, err error) {
+	return New(w, mode.Color(w)).Printf(format, a...)
+}
+
+// PrintfStyle calls the default Printer's PrintfStyle method.
+func PrintfStyle(s Style, format string, a ...interface{}) (n int, err error) {
+	return stdPrinter().PrintfStyle(s, format, a...)
 }
 
-// Printfp calls the standard output Printer's Printfp method.
+// Printfp calls the default Printer's Printfp method.
 func Printfp(f *Format, a ...interface{}) (n int, err error) {
-	return std.Printfp(f, a...)
+	return stdPrinter().Printfp(f, a...)
 }
 
-// Print calls the standard output Printer's Print method.
+// Print calls the default Printer's Print method.
 func Print(a ...interface{}) (n int, err error) {
-	return std.Print(a...)
+	return stdPrinter().Print(a...)
 }
 
-// Println calls the standard output Printer's Println method.
+// Println calls the default Printer's Println method.
 func Println(a ...interface{}) (n int, err error) {
-	return std.Println(a...)
+	return stdPrinter().Println(a...)
 }
@@ -2,8 +2,13 @@ package color
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"sync"
 	"testing"
+
+	"github.com/nhooyr/terminfo/caps"
 )
 
 func TestPrintf(t *testing.T) {
@@ -84,3 +89,510 @@ func TestPrintln(t *testing.T) {
 		t.Errorf("Expected %q but result was %q", exp, b.String())
 	}
 }
+
+func TestWriteRaw(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	raw := Highlight("%h[fgRed]100% %h[bold]done%r\n")
+	p := New(&b, true)
+	p.WriteRaw([]byte(raw))
+	if b.String() != raw {
+		t.Errorf("Expected %q but result was %q", raw, b.String())
+	}
+	b.Reset()
+	p = New(&b, false)
+	n, err := p.WriteRaw([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "100% done\n"; b.String() != exp || n != len(exp) {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p = New(&b, true)
+	p.SetBaseStyle("fg245")
+	p.WriteRaw([]byte("a\x1b[0mb"))
+	base := Highlight("%h[fg245]")
+	if exp := base + "a\x1b[0m" + base + "b" + reset(); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestSetBaseStyle(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	base := Highlight("%h[fg245+dim]")
+	p := New(&b, true)
+	if err := p.SetBaseStyle("fg245+dim"); err != nil {
+		t.Fatal(err)
+	}
+	p.Printf("%h[fgRed]foo%r %s\n", "bar")
+	exp := base + ti.Color(caps.Red, -1) + "foo" + reset() + base + " bar\n" + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printfp(Prepare("%h[bold]foo%r"))
+	exp = base + ti.Strings[caps.EnterBoldMode] + "foo" + reset() + base + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Println("foo", "bar")
+	exp = base + "foo bar\n" + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	if err := p.SetBaseStyle("fgRedd"); err == nil {
+		t.Errorf("Expected error from %q", "fgRedd")
+	}
+	p.Print("foo")
+	if exp = base + "foo" + reset(); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p = New(&b, false)
+	p.SetBaseStyle("fg245+dim")
+	p.Printf("%h[fgRed]foo%r %s\n", "bar")
+	exp = "foo bar\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestPrintfWidth(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	const s = "%h[fgBlue]bar:%r %s %d"
+	p := New(&b, true)
+	w, err := p.PrintfWidth(s, "世界", 42)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := fmt.Sprintf(Highlight(s), "世界", 42)
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	if w != 12 {
+		t.Errorf("Expected width %d but result was %d", 12, w)
+	}
+}
+
+func TestAutoResetEnd(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(AutoResetEnd)
+	formats := map[string]string{
+		"%h[fgRed]foo %s":      Highlight("%h[fgRed]foo bar") + reset(),
+		"%h[fgRed]foo %s%r":    Highlight("%h[fgRed]foo bar%r"),
+		"%h[fgRed]foo%r %s":    Highlight("%h[fgRed]foo%r bar"),
+		"%h[bold+reset]foo %s": Highlight("%h[bold+reset]foo bar"),
+		"foo %s":               "foo bar",
+	}
+	for k, v := range formats {
+		b.Reset()
+		p.Printf(k, "bar")
+		if b.String() != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, b.String())
+		}
+		b.Reset()
+		p.Printfp(Prepare(k), "bar")
+		if b.String() != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, b.String())
+		}
+	}
+	b.Reset()
+	p = New(&b, false)
+	p.SetFlags(AutoResetEnd)
+	p.Printf("%h[fgRed]foo %s", "bar")
+	if b.String() != "foo bar" {
+		t.Errorf("Expected %q but result was %q", "foo bar", b.String())
+	}
+}
+
+func TestForce16(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(Force16)
+	formats := map[string]string{
+		"%h[fg196]%s":          Highlight("%h[fgBrightRed]") + "foo",
+		"%h[bg16+fg3]%s":       Highlight("%h[bgBlack+fgYellow]") + "foo",
+		"%h[fg#0000f0]%s":      Highlight("%h[fgBlue]") + "foo",
+		"%h[bgFill231+bold]%s": Highlight("%h[bgFillBrightWhite+bold]") + "foo",
+		"%h[fgDefault]%s":      Highlight("%h[fgDefault]") + "foo",
+		"%h[bggray23]%s":       Highlight("%h[bgWhite]") + "foo",
+		"%h[ulcolor#0000f0]%s": Highlight("%h[ulcolorBlue]") + "foo",
+		"%h[ulcolor196]%s":     Highlight("%h[ulcolorBrightRed]") + "foo",
+	}
+	for k, v := range formats {
+		b.Reset()
+		p.Printf(k, "foo")
+		if b.String() != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, b.String())
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(AutoResetEnd)
+	p.SetBaseStyle("bold")
+	c := p.Clone()
+	if c.Flags() != AutoResetEnd {
+		t.Errorf("Expected flags %d but result was %d", AutoResetEnd, c.Flags())
+	}
+	c.SetBaseStyle("fgRed")
+	c.SetFlags(0)
+	p.Printf("foo")
+	exp := Highlight("%h[bold]foo") + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	c.Printf("foo")
+	exp = Highlight("%h[fgRed]foo") + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	if p.Flags() != AutoResetEnd {
+		t.Errorf("Expected flags %d but result was %d", AutoResetEnd, p.Flags())
+	}
+}
+
+func TestMinifyOutput(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(MinifyOutput)
+	f := Prepare("%h[bold]")
+	exp := Minify(Highlight("%h[fgRed]%h[bold]foo%r%h[bgBlue]"))
+	p.Printf("%h[fgRed]%vfoo%r%h[bgBlue]", f)
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Print(Highlight("%h[fgRed]"), Highlight("%h[bold]"))
+	exp = Minify(Highlight("%h[fgRed]%h[bold]"))
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestVprintf(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.Vprintf(1, "%h[fgRed]foo")
+	if b.String() != "" {
+		t.Errorf("Expected no output but result was %q", b.String())
+	}
+	p.SetVerbosity(2)
+	p.Vprintf(3, "%h[fgRed]foo")
+	p.Vprintf(2, "%h[fgRed]foo %s", "bar")
+	p.Vprintf(-1, "%h[fgRed]foo")
+	exp := Highlight("%h[fgRed]foo bar%h[fgRed]foo")
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func BenchmarkVprintfSuppressed(b *testing.B) {
+	p := New(ioutil.Discard, true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.Vprintf(1, "%h[fgRed]foo %s %d", "bar", 42)
+	}
+}
+
+func TestPassthroughUnknown(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(PassthroughUnknown | AutoResetEnd)
+	formats := map[string]string{
+		"%h[foo]%s %h[bold]x":       "%h[foo]bar " + Highlight("%h[bold]x") + reset(),
+		"%h[fgRed+foo+bold]%s":      "%h[fgRed+foo+bold]bar",
+		"%h[fgRed]%h[fgGdsds]%s%r":  Highlight("%h[fgRed]") + "%h[fgGdsds]bar" + Highlight("%r"),
+		"%h[bold+raw=1;fgRed:2] %s": "%h[bold+raw=1;fgRed:2] bar",
+		"%s %h[foo":                 "bar %!h(SHORT)",
+		"%h(bold)%s":                "%!h(INVALID)%!(EXTRA string=bar)",
+	}
+	for k, v := range formats {
+		b.Reset()
+		p.Printf(k, "bar")
+		if b.String() != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, b.String())
+		}
+	}
+	b.Reset()
+	p.SetFlags(0)
+	p.Printf("%h[foo]%s", "bar")
+	if exp := "%!h(BADATTR)%!(EXTRA string=bar)"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+// Not parallel because it changes global state.
+func TestSetDefaultOutput(t *testing.T) {
+	w, mode := DefaultOutput()
+	defer SetDefaultOutput(w, mode)
+	var b bytes.Buffer
+	SetDefaultOutput(&b, EnableColor)
+	if w, mode := DefaultOutput(); w != &b || mode != EnableColor {
+		t.Errorf("Expected %p and %v but result was %p and %v", &b, EnableColor, w, mode)
+	}
+	Printf("%h[fgRed]%s%r", "foo")
+	Print("bar")
+	exp := Highlight("%h[fgRed]foo%r") + "bar"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	SetDefaultOutput(&b, PerformCheck)
+	Println("%h[fgRed]", Prepare("%h[bold]baz"))
+	if exp := "%h[fgRed] baz\n"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestCaptureDefault(t *testing.T) {
+	w, mode := DefaultOutput()
+	defer SetDefaultOutput(w, mode)
+	var b bytes.Buffer
+	SetDefaultOutput(&b, EnableColor)
+	names := []string{"foo", "bar", "baz"}
+	outs := make([]string, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			outs[i] = CaptureDefault(func() {
+				Printf("%h[fgRed]%s%r", name)
+				Println(name)
+			})
+		}(i, name)
+	}
+	wg.Wait()
+	for i, name := range names {
+		if exp := Highlight("%h[fgRed]"+name+"%r") + name + "\n"; outs[i] != exp {
+			t.Errorf("Expected %q but result was %q", exp, outs[i])
+		}
+	}
+	func() {
+		defer func() {
+			recover()
+		}()
+		CaptureDefault(func() {
+			panic("foo")
+		})
+	}()
+	Print("foo")
+	if b.String() != "foo" {
+		t.Errorf("Expected %q but result was %q", "foo", b.String())
+	}
+	b.Reset()
+	SetDefaultOutput(&b, DisableColor)
+	if out := CaptureDefault(func() { Printf("%h[fgRed]foo%r") }); out != "foo" {
+		t.Errorf("Expected %q but result was %q", "foo", out)
+	}
+	Print("bar")
+	if b.String() != "bar" {
+		t.Errorf("Expected %q but result was %q", "bar", b.String())
+	}
+}
+
+func TestSynchronized(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	errFoo := errors.New("foo")
+	err := p.Synchronized(func(p *Printer) error {
+		p.Printf("%h[fgRed]foo%r")
+		if b.Len() != 0 {
+			t.Errorf("Expected no output before the end but result was %q", b.String())
+		}
+		p.Print("bar")
+		return errFoo
+	})
+	if err != errFoo {
+		t.Errorf("Expected %v but result was %v", errFoo, err)
+	}
+	exp := Highlight("%h[fgRed]foo%r") + "bar"
+	if tiErr == nil {
+		exp = beginSync + exp + endSync
+	}
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p = New(&b, false)
+	p.Synchronized(func(p *Printer) error {
+		_, err := p.Printf("%h[fgRed]foo%r")
+		return err
+	})
+	if b.String() != "foo" {
+		t.Errorf("Expected %q but result was %q", "foo", b.String())
+	}
+	b.Reset()
+	p.SetIndent(2, "")
+	p.Print("foo")
+	p.Synchronized(func(p *Printer) error {
+		_, err := p.Print("bar\nbaz\n")
+		return err
+	})
+	p.Print("qux")
+	if exp := "  foobar\n  baz\n  qux"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestPrintfStyle(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	st := Style{Fg: PaletteColor(1), Attrs: Bold}
+	p := New(&b, true)
+	p.PrintfStyle(st, "%s %h[underline]bar", "foo")
+	exp := Highlight("%h[fgRed+bold]") + "foo " + ti.Strings[caps.EnterUnderlineMode] + "bar" + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.PrintfStyle(st, "foo%r")
+	exp = Highlight("%h[fgRed+bold]") + "foo" + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.SetBaseStyle("dim")
+	p.PrintfStyle(st, "foo")
+	exp = Highlight("%h[dim]%h[fgRed+bold]") + "foo" + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p = New(&b, true)
+	p.SetFlags(Force16)
+	p.PrintfStyle(Style{Fg: TrueColor(0, 0, 0xf0)}, "foo")
+	exp = Highlight("%h[fgBlue]") + "foo" + reset()
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p = New(&b, false)
+	p.PrintfStyle(st, "%h[bold]foo%s", "bar")
+	if b.String() != "foobar" {
+		t.Errorf("Expected %q but result was %q", "foobar", b.String())
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if tiErr == nil && !New(&b, true).ColorEnabled() {
+		t.Errorf("Expected color to be enabled")
+	}
+	if New(&b, false).ColorEnabled() {
+		t.Errorf("Expected color to be disabled")
+	}
+	modes := map[Mode]bool{EnableColor: tiErr == nil, DisableColor: false, PerformCheck: false}
+	for m, exp := range modes {
+		if r := NewBufferedPrinter(&b, m, 16).ColorEnabled(); r != exp {
+			t.Errorf("Expected %v from %v but result was %v", exp, m, r)
+		}
+	}
+}
+
+func TestFprintfMode(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	modes := map[Mode]string{
+		EnableColor:  Highlight("%h[fgRed]foo%r 1"),
+		DisableColor: "foo 1",
+		PerformCheck: "foo 1",
+	}
+	for m, exp := range modes {
+		b.Reset()
+		FprintfMode(&b, m, "%h[fgRed]foo%r %d", 1)
+		if b.String() != exp {
+			t.Errorf("Expected %q from %v but result was %q", exp, m, b.String())
+		}
+	}
+}
+
+func TestColonSubparams(t *testing.T) {
+	t.Parallel()
+	const format = "%h[fg#ff0080+underline=curly+ulcolor208+bg236]hi"
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.Printf(format)
+	exp := "\x1b[38;2;255;0;128m" + ti.Strings[caps.EnterUnderlineMode] + "\x1b[4:3m\x1b[58;5;208m" + ti.Color(-1, 236) + "hi"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.SetFlags(ColonSubparams)
+	p.Printf(format)
+	exp = "\x1b[38:2::255:0:128m" + ti.Strings[caps.EnterUnderlineMode] + "\x1b[4:3m\x1b[58:5:208m\x1b[48:5:236mhi"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printf("%h[fgRed+bgFill236]hi")
+	exp = ti.Color(caps.Red, -1) + "\x1b[48:5:236m\x1b[Khi"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestForce256(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(Force256)
+	formats := map[string]string{
+		"%h[fg#d70000]%s":       Highlight("%h[fg160]") + "foo",
+		"%h[bg#080808+bold]%s":  Highlight("%h[bg232+bold]") + "foo",
+		"%h[fg200]%s":           Highlight("%h[fg200]") + "foo",
+		"%h[ulcolor#5f87af]%s":  Highlight("%h[ulcolor67]") + "foo",
+		"%h[fgRed+bg#5f87af]%s": Highlight("%h[fgRed+bg67]") + "foo",
+	}
+	for k, v := range formats {
+		b.Reset()
+		p.Printf(k, "foo")
+		if b.String() != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, b.String())
+		}
+	}
+}
+
+func TestPrintfLevel(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(Force16)
+	const format = "%h[fg#d70000+bg#5f87af+ulcolor#5f87af]%s%r"
+	levels := map[Level]string{
+		LevelTrueColor: Highlight("%h[fg#d70000+bg#5f87af+ulcolor#5f87af]") + "foo" + reset(),
+		Level256:       Highlight("%h[fg160+bg67+ulcolor67]") + "foo" + reset(),
+		LevelBasic:     Highlight("%h[fgRed+bgBrightBlack+ulcolorBrightBlack]") + "foo" + reset(),
+		LevelNone:      "foo",
+	}
+	for k, v := range levels {
+		b.Reset()
+		p.PrintfLevel(k, format, "foo")
+		if b.String() != v {
+			t.Errorf("Expected %q at level %d but result was %q", v, k, b.String())
+		}
+	}
+	b.Reset()
+	p.Printf(format, "foo")
+	if v := levels[LevelBasic]; b.String() != v {
+		t.Errorf("Expected %q but result was %q", v, b.String())
+	}
+}
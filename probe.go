@@ -0,0 +1,71 @@
+package color
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// probeColor is the true color ProbeTrueColor sets, chosen to be far from the 256 colors
+// so that a terminal that quantizes true colors does not report it back.
+var probeColor = RGB{0x12, 0x34, 0x56}
+
+// ProbeTrueColor asks the terminal tty whether it renders true colors exactly, as many
+// terminals that claim to support them, e.g. in COLORTERM, quantize them to the 256 colors.
+// It sets a true color foreground and then asks the terminal for the attributes in effect
+// with a DECRQSS query. It returns true only if the terminal reports the exact color back.
+// The attributes reported by a first query are set again afterwards.
+//
+// The tty must be the terminal itself, e.g. opened from /dev/tty, and it is put in raw mode
+// for the duration of the probe so that the answers are not echoed, then restored to its
+// previous mode. Each answer is waited for at most timeout, an error is returned if the
+// terminal does not answer in time, which includes terminals that do not support DECRQSS,
+// or if tty does not support read deadlines. Like BackgroundIsDark, it must not be used
+// while another goroutine reads from the terminal. It is not supported on Windows.
+func ProbeTrueColor(tty *os.File, timeout time.Duration) (bool, error) {
+	return probeTrueColor(tty, timeout)
+}
+
+// decrqssSGR is the DECRQSS query for the SGR attributes in effect.
+const decrqssSGR = "\x1bP$qm\x1b\\"
+
+// parseDECRQSS returns the SGR parameters in resp, the answer of the terminal to a DECRQSS
+// query for them such as "\x1bP1$r0;38;2;1;2;3m\x1b\\".
+func parseDECRQSS(resp string) (params string, ok bool) {
+	i := strings.Index(resp, "$r")
+	if i == -1 || i == 0 || resp[i-1] != '1' {
+		return "", false
+	}
+	resp = resp[i+len("$r"):]
+	j := strings.IndexByte(resp, 'm')
+	if j == -1 {
+		return "", false
+	}
+	return resp[:j], true
+}
+
+// hasTrueColor returns true if the SGR parameters params set the foreground to c, in the
+// semicolon form "38;2;r;g;b" or either colon form, "38:2:r:g:b" or "38:2:cs:r:g:b".
+func hasTrueColor(params string, c RGB) bool {
+	codes := strings.Split(params, ";")
+	for i, code := range codes {
+		var rgb []string
+		switch {
+		case code == "38" && i+4 < len(codes) && codes[i+1] == "2":
+			rgb = codes[i+2 : i+5]
+		case strings.HasPrefix(code, "38:2:"):
+			sub := strings.Split(code, ":")
+			if len(sub) != 5 && len(sub) != 6 {
+				continue
+			}
+			rgb = sub[len(sub)-3:]
+		default:
+			continue
+		}
+		if rgb[0] == strconv.Itoa(int(c.R)) && rgb[1] == strconv.Itoa(int(c.G)) && rgb[2] == strconv.Itoa(int(c.B)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+// +build !windows
+
+package color
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+func probeTrueColor(tty *os.File, timeout time.Duration) (bool, error) {
+	fd := int(tty.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return false, fmt.Errorf("color: cannot put the terminal in raw mode: %v", err)
+	}
+	defer terminal.Restore(fd, state)
+	prev, err := queryDECRQSS(tty, timeout)
+	if err != nil {
+		return false, err
+	}
+	_, err = fmt.Fprintf(tty, "\x1b[38;2;%d;%d;%dm", probeColor.R, probeColor.G, probeColor.B)
+	if err != nil {
+		return false, err
+	}
+	params, err := queryDECRQSS(tty, timeout)
+	// Set the previous attributes again whatever the answer.
+	if _, werr := fmt.Fprintf(tty, "\x1b[0;%sm", prev); err == nil {
+		err = werr
+	}
+	if err != nil {
+		return false, err
+	}
+	return hasTrueColor(params, probeColor), nil
+}
+
+// queryDECRQSS asks tty for the SGR attributes in effect and returns their parameters.
+func queryDECRQSS(tty *os.File, timeout time.Duration) (string, error) {
+	if _, err := tty.WriteString(decrqssSGR); err != nil {
+		return "", err
+	}
+	resp, ok := readAnswer(tty, timeout)
+	if !ok {
+		return "", fmt.Errorf("color: no answer from the terminal within %v", timeout)
+	}
+	params, ok := parseDECRQSS(resp)
+	if !ok {
+		return "", fmt.Errorf("color: bad DECRQSS answer %q", resp)
+	}
+	return params, nil
+}
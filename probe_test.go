@@ -0,0 +1,48 @@
+package color
+
+import "testing"
+
+var decrqsss = []struct {
+	resp   string
+	params string
+	ok     bool
+}{
+	{"\x1bP1$r0;38;2;1;2;3m\x1b\\", "0;38;2;1;2;3", true},
+	{"\x1bP1$rm\x1b\\", "", true},
+	{"\x1bP1$r0;38:2::1:2:3m\x1b\\", "0;38:2::1:2:3", true},
+	{"\x1bP0$r\x1b\\", "", false},
+	{"\x1bP1$r0;1\x1b\\", "", false},
+	{"\x1b]11;rgb:0/0/0\x07", "", false},
+}
+
+func TestParseDECRQSS(t *testing.T) {
+	t.Parallel()
+	for _, tc := range decrqsss {
+		if params, ok := parseDECRQSS(tc.resp); params != tc.params || ok != tc.ok {
+			t.Errorf("Expected %q, %v from %q but result was %q, %v", tc.params, tc.ok, tc.resp, params, ok)
+		}
+	}
+}
+
+var trueColorParams = map[string]bool{
+	"0;38;2;18;52;86":   true,
+	"1;38:2:18:52:86":   true,
+	"38:2::18:52:86;4":  true,
+	"38:2:0:18:52:86":   true,
+	"48;2;18;52;86":     false,
+	"38;5;24":           false,
+	"0;38;2;18;52;85":   false,
+	"38;2;18;52":        false,
+	"38:2:1:2:18:52:86": false,
+	"0;34":              false,
+	"":                  false,
+}
+
+func TestHasTrueColor(t *testing.T) {
+	t.Parallel()
+	for k, v := range trueColorParams {
+		if r := hasTrueColor(k, probeColor); r != v {
+			t.Errorf("Expected %v from %q but result was %v", v, k, r)
+		}
+	}
+}
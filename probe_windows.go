@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package color
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// probeTrueColor is not supported as the console cannot be queried with escape sequences.
+func probeTrueColor(tty *os.File, timeout time.Duration) (bool, error) {
+	return false, errors.New("color: ProbeTrueColor is not supported on Windows")
+}
@@ -0,0 +1,189 @@
+/*
+Package progress implements a colored progress bar for terminals.
+
+A Bar is drawn on a single line as its fill and empty characters between brackets
+followed by the percentage, e.g. "[####----]  50%". Every update redraws the line
+in place by returning the cursor to its start.
+
+	bar := progress.New(os.Stderr, len(files))
+	for _, f := range files {
+		process(f)
+		bar.Add(1)
+	}
+	bar.Finish()
+*/
+package progress
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nhooyr/color"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// defaultWidth is the width of the bar when it is not set and the
+// width of the terminal cannot be determined.
+const defaultWidth = 40
+
+// clearLine is the sequence that clears the line the cursor is on.
+const clearLine = "\x1b[2K"
+
+// Bar is a progress bar. It is safe for concurrent use.
+type Bar struct {
+	mu    sync.Mutex
+	w     io.Writer
+	total int
+	cur   int
+	width int  // columns between the brackets, 0 to use the width of the terminal
+	color bool // enable color output
+	fill  string
+	empty string
+	done  bool
+}
+
+// New creates a new Bar that writes to w and is complete once total is reached.
+// Color output is enabled if w is a terminal that supports it, as with
+// color.PerformCheck. The fill is green and the empty part is bright black.
+// Nothing is drawn until the first update.
+func New(w io.Writer, total int) *Bar {
+	b := &Bar{w: w, total: total, color: color.PerformCheck.Color(w)}
+	// The default specs are valid.
+	b.fill, _ = color.Codes("fgGreen")
+	b.empty, _ = color.Codes("fgBrightBlack")
+	return b
+}
+
+// SetColor sets whether colored output is enabled, e.g. to follow a Printer
+// with b.SetColor(p.ColorEnabled()). Without color, neither the styles nor
+// the sequence that clears the line are written.
+func (b *Bar) SetColor(color bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.color = color
+}
+
+// SetStyles sets the styles of the fill and the empty part of b. The specs are the
+// lists of attributes as they would appear between the brackets of the highlight
+// verb, e.g. "fgBlue+bold". It returns an error if either spec is invalid.
+func (b *Bar) SetStyles(fill, empty string) error {
+	f, err := color.Codes(fill)
+	if err != nil {
+		return err
+	}
+	e, err := color.Codes(empty)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fill, b.empty = f, e
+	return nil
+}
+
+// SetWidth sets the number of columns between the brackets of b. A width less
+// than 1 makes b fit the width of the terminal, which is the default.
+func (b *Bar) SetWidth(width int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.width = width
+}
+
+// Add adds n to the progress of b and redraws it.
+func (b *Bar) Add(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(b.cur + n)
+}
+
+// Set sets the progress of b to n and redraws it.
+func (b *Bar) Set(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(n)
+}
+
+// Finish redraws b a final time and ends its line, leaving the cursor at the start of
+// the next line with no attributes active. Updates after Finish are ignored.
+func (b *Bar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	b.draw()
+	io.WriteString(b.w, "\n")
+	b.done = true
+}
+
+// set sets the progress to n, clamped to the total, and redraws b.
+func (b *Bar) set(n int) {
+	if b.done {
+		return
+	}
+	if n < 0 {
+		n = 0
+	} else if n > b.total {
+		n = b.total
+	}
+	b.cur = n
+	b.draw()
+}
+
+// draw writes the line of b.
+func (b *Bar) draw() {
+	width := b.barWidth()
+	percent := 100
+	if b.total > 0 {
+		percent = b.cur * 100 / b.total
+	}
+	filled := width * percent / 100
+	var buf bytes.Buffer
+	buf.WriteByte('\r')
+	if b.color {
+		buf.WriteString(clearLine)
+	}
+	buf.WriteByte('[')
+	b.writePart(&buf, b.fill, "#", filled)
+	b.writePart(&buf, b.empty, "-", width-filled)
+	buf.WriteString("] ")
+	p := strconv.Itoa(percent)
+	buf.WriteString(strings.Repeat(" ", 3-len(p)))
+	buf.WriteString(p)
+	buf.WriteByte('%')
+	b.w.Write(buf.Bytes())
+}
+
+// writePart writes n characters ch in the style start.
+func (b *Bar) writePart(buf *bytes.Buffer, start, ch string, n int) {
+	if n == 0 {
+		return
+	}
+	if b.color && start != "" {
+		buf.WriteString(start)
+		buf.WriteString(strings.Repeat(ch, n))
+		buf.WriteString(color.Reset)
+		return
+	}
+	buf.WriteString(strings.Repeat(ch, n))
+}
+
+// barWidth returns the number of columns between the brackets.
+func (b *Bar) barWidth() int {
+	if b.width > 0 {
+		return b.width
+	}
+	if f, ok := b.w.(*os.File); ok {
+		// Leave room for the brackets and the percentage, " 100%", and a column
+		// so that the line does not wrap on terminals that wrap at the last column.
+		cols, _, err := terminal.GetSize(int(f.Fd()))
+		if err == nil && cols > len("[] 100%")+1 {
+			return cols - len("[] 100%") - 1
+		}
+	}
+	return defaultWidth
+}
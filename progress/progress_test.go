@@ -0,0 +1,67 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nhooyr/color"
+)
+
+func TestBar(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	b := New(&buf, 200)
+	b.SetWidth(8)
+	b.Set(100)
+	b.Add(150)
+	b.Finish()
+	b.Add(1)
+	exp := "\r[####----]  50%\r[########] 100%\r[########] 100%\n"
+	if buf.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, buf.String())
+	}
+}
+
+func TestBarColor(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	b := New(&buf, 4)
+	b.SetWidth(4)
+	b.SetColor(true)
+	if err := b.SetStyles("fgBlue", "fgRedd"); err == nil {
+		t.Errorf("Expected an error from an invalid spec")
+	}
+	if err := b.SetStyles("fgBlue", "dim"); err != nil {
+		t.Fatal(err)
+	}
+	b.Set(1)
+	fill, _ := color.Codes("fgBlue")
+	empty, _ := color.Codes("dim")
+	exp := "\r" + clearLine + "[" + fill + "#" + color.Reset + empty + "---" + color.Reset + "]  25%"
+	if buf.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, buf.String())
+	}
+}
+
+func TestBarConcurrent(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	b := New(&buf, 100)
+	b.SetWidth(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				b.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if !strings.HasSuffix(buf.String(), "\r[##########] 100%") {
+		t.Errorf("Expected the bar to be complete but result was %q", buf.String()[buf.Len()-20:])
+	}
+}
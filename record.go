@@ -0,0 +1,70 @@
+package color
+
+import (
+	"io"
+	"sync"
+)
+
+// Recorder records the writes of a Printer. It is meant for asserting on exactly what
+// was printed in tests and for replaying a captured session to another writer.
+// It is safe for concurrent use.
+type Recorder struct {
+	p *Printer // writes to the Recorder
+
+	mu     sync.Mutex
+	out    io.Writer // writer of the wrapped Printer, nil to only record
+	frames []string
+}
+
+// NewRecorder returns a Recorder that records the writes of a Printer with the settings
+// of p, which is returned by its Printer method. The writes are also written to the
+// writer of p unless it is nil. Every print method of a Printer writes its output at
+// once, so each call is a frame.
+func NewRecorder(p *Printer) *Recorder {
+	r := &Recorder{out: p.out}
+	r.p = p.Clone()
+	r.p.out = recorderWriter{r}
+	return r
+}
+
+// Printer returns the Printer whose writes r records.
+func (r *Recorder) Printer() *Printer {
+	return r.p
+}
+
+// Frames returns the recorded writes in order. Each is exactly the bytes written.
+func (r *Recorder) Frames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	frames := make([]string, len(r.frames))
+	copy(frames, r.frames)
+	return frames
+}
+
+// Replay writes the recorded writes to w in order, each with a separate write.
+// It stops at the first error.
+func (r *Recorder) Replay(w io.Writer) error {
+	for _, f := range r.Frames() {
+		if _, err := io.WriteString(w, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recorderWriter records the writes to it in a Recorder.
+// It keeps Write out of the methods of Recorder.
+type recorderWriter struct {
+	r *Recorder
+}
+
+func (rw recorderWriter) Write(p []byte) (n int, err error) {
+	r := rw.r
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, string(p))
+	if r.out == nil {
+		return len(p), nil
+	}
+	return r.out.Write(p)
+}
@@ -0,0 +1,35 @@
+package color
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	r := NewRecorder(New(&b, true))
+	p := r.Printer()
+	p.Printf("%h[fgRed]foo%r\n")
+	p.Println("bar", 1)
+	exp := []string{Highlight("%h[fgRed]foo%r\n"), "bar 1\n"}
+	if frames := r.Frames(); !reflect.DeepEqual(frames, exp) {
+		t.Errorf("Expected %q but result was %q", exp, frames)
+	}
+	if b.String() != exp[0]+exp[1] {
+		t.Errorf("Expected %q but result was %q", exp[0]+exp[1], b.String())
+	}
+	var b2 bytes.Buffer
+	if err := r.Replay(&b2); err != nil {
+		t.Fatal(err)
+	}
+	if b2.String() != b.String() {
+		t.Errorf("Expected %q but result was %q", b.String(), b2.String())
+	}
+	r = NewRecorder(New(nil, false))
+	r.Printer().Printf("%h[fgRed]foo")
+	if frames := r.Frames(); !reflect.DeepEqual(frames, []string{"foo"}) {
+		t.Errorf("Expected %q but result was %q", []string{"foo"}, frames)
+	}
+}
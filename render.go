@@ -0,0 +1,171 @@
+package color
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Cell is a cell of the grid of a terminal rendered by Render.
+type Cell struct {
+	Rune  rune  // rune in the cell, 0 for the right half of a wide rune
+	Style Style // style the rune was written in
+}
+
+// Render returns the grid of cells that a terminal with cols columns shows after the
+// output s, e.g. the output of a Printer, is written to it, with one slice of cells for
+// each row up to and including the row of the cursor. It is meant for tests to check
+// what the output looks like, such as the rune and style at a given row and column,
+// rather than comparing escape sequences.
+//
+// Only the sequences that this package writes are rendered: SGR sequences change the
+// style, a newline moves the cursor to the start of the next row, a carriage return to
+// the start of the row and a tab to the next multiple of 8 columns. The sequences
+// written by clearline, clear and bgFill are rendered as well: erasing a row, erasing the
+// screen, moving the cursor to its top left corner and erasing to the end of the row,
+// erasing with the background color in effect like most terminals. Other escape sequences
+// and control characters are ignored, as are zero width runes such as combining marks.
+//
+// A row only has as many cells as were written to or erased with a background color,
+// cells skipped over hold spaces in the default style. A rune that does not fit in the
+// rest of a row is written at the start of the next row. If cols is less than 1, rows
+// are never wrapped and erasing to the end of a row erases up to the cells written.
+func Render(s string, cols int) [][]Cell {
+	r := renderer{cols: cols, rows: make([][]Cell, 1)}
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if n := escapeLen(s[i:]); n > 0 {
+				r.escape(s[i : i+n])
+				i += n
+				continue
+			}
+		}
+		ch, n := utf8.DecodeRuneInString(s[i:])
+		i += n
+		switch ch {
+		case '\n':
+			r.row++
+			r.col = 0
+			r.grow()
+		case '\r':
+			r.col = 0
+		case '\t':
+			r.col += 8 - r.col%8
+			if cols > 0 && r.col >= cols {
+				r.col = cols - 1
+			}
+		default:
+			if w := runeWidth(ch); w > 0 {
+				r.write(ch, w)
+			}
+		}
+	}
+	return r.rows
+}
+
+// renderer renders output for Render.
+type renderer struct {
+	cols     int
+	rows     [][]Cell
+	row, col int // position of the cursor
+	st       Style
+}
+
+// grow adds rows up to the row of the cursor.
+func (r *renderer) grow() {
+	for len(r.rows) <= r.row {
+		r.rows = append(r.rows, nil)
+	}
+}
+
+// write writes ch, which occupies w columns, at the cursor and moves the cursor past it.
+func (r *renderer) write(ch rune, w int) {
+	if r.cols > 0 && r.col+w > r.cols {
+		r.row++
+		r.col = 0
+		r.grow()
+	}
+	r.set(r.col, Cell{ch, r.st})
+	if w == 2 {
+		r.set(r.col+1, Cell{0, r.st})
+	}
+	r.col += w
+}
+
+// set sets the cell at col of the row of the cursor to c.
+func (r *renderer) set(col int, c Cell) {
+	row := r.rows[r.row]
+	for len(row) <= col {
+		row = append(row, Cell{Rune: ' '})
+	}
+	row[col] = c
+	r.rows[r.row] = row
+}
+
+// escape renders the escape sequence e.
+func (r *renderer) escape(e string) {
+	if params, n := sgrAt(e); n > 0 {
+		r.st = r.st.apply(params)
+		return
+	}
+	if len(e) < 3 || e[1] != '[' {
+		return
+	}
+	params := e[2 : len(e)-1]
+	switch e[len(e)-1] {
+	case 'K':
+		switch params {
+		case "", "0":
+			r.erase(r.col, r.end())
+		case "1":
+			r.erase(0, r.col+1)
+		case "2":
+			r.erase(0, r.end())
+		}
+	case 'J':
+		if params == "2" {
+			for i := range r.rows {
+				r.rows[i] = nil
+			}
+		}
+	case 'H':
+		r.row, r.col = 0, 0
+		if p := strings.Split(params, ";"); params != "" {
+			if n, err := strconv.Atoi(p[0]); err == nil && n > 0 {
+				r.row = n - 1
+			}
+			if len(p) > 1 {
+				if n, err := strconv.Atoi(p[1]); err == nil && n > 0 {
+					r.col = n - 1
+				}
+			}
+		}
+		r.grow()
+	}
+}
+
+// end returns the column after the last one an erase to the end of the row erases.
+func (r *renderer) end() int {
+	if r.cols > 0 {
+		return r.cols
+	}
+	return len(r.rows[r.row])
+}
+
+// erase erases the cells from start up to end of the row of the cursor with the
+// background color in effect. Erased cells past the cells written are only added
+// if the background color is not the default.
+func (r *renderer) erase(start, end int) {
+	c := Cell{' ', Style{Bg: r.st.Bg}}
+	row := r.rows[r.row]
+	if c.Style.isDefault() && end > len(row) {
+		end = len(row)
+	}
+	for i := start; i < end; i++ {
+		r.set(i, c)
+	}
+	if c.Style.isDefault() && end == len(r.rows[r.row]) && start < end {
+		// Drop the trailing blank cells.
+		r.rows[r.row] = r.rows[r.row][:start]
+	}
+}
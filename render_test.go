@@ -0,0 +1,53 @@
+package color
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+	red := Style{Fg: PaletteColor(1)}
+	blue := Style{Bg: PaletteColor(4)}
+	tests := []struct {
+		s    string
+		cols int
+		exp  [][]Cell
+	}{
+		{"", 10, [][]Cell{nil}},
+		{"ab\n", 10, [][]Cell{{{'a', Style{}}, {'b', Style{}}}, nil}},
+		{"\x1b[31ma\x1b[0mb", 10, [][]Cell{{{'a', red}, {'b', Style{}}}}},
+		{"abc", 2, [][]Cell{{{'a', Style{}}, {'b', Style{}}}, {{'c', Style{}}}}},
+		{"a世", 2, [][]Cell{{{'a', Style{}}}, {{'世', Style{}}, {0, Style{}}}}},
+		{"abc\rx", 10, [][]Cell{{{'x', Style{}}, {'b', Style{}}, {'c', Style{}}}}},
+		{"abc\r\x1b[2Kx", 10, [][]Cell{{{'x', Style{}}}}},
+		{"abc\rx\x1b[K", 10, [][]Cell{{{'x', Style{}}}}},
+		{"a\tb", 10, [][]Cell{{{'a', Style{}}, {' ', Style{}}, {' ', Style{}}, {' ', Style{}}, {' ', Style{}}, {' ', Style{}}, {' ', Style{}}, {' ', Style{}}, {'b', Style{}}}}},
+		{"\x1b[44ma\x1b[K\x1b[0m", 3, [][]Cell{{{'a', blue}, {' ', blue}, {' ', blue}}}},
+		{"ab\ncd\x1b[2J\x1b[Hx", 10, [][]Cell{{{'x', Style{}}}, nil}},
+		{"é\x1b]0;title\a\a", 10, [][]Cell{{{'e', Style{}}}}},
+	}
+	for _, tc := range tests {
+		if r := Render(tc.s, tc.cols); !reflect.DeepEqual(r, tc.exp) {
+			t.Errorf("Expected %v from %q but result was %v", tc.exp, tc.s, r)
+		}
+	}
+}
+
+func TestRenderPrinter(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.Printf("%h[bold]foo%r\n%h[fgRed]bar%r\n")
+	if tiErr != nil {
+		t.Skip(tiErr)
+	}
+	grid := Render(b.String(), 80)
+	if c := grid[1][2]; c.Rune != 'r' || c.Style != (Style{Fg: PaletteColor(1)}) {
+		t.Errorf("Expected a red 'r' but result was %+v", c)
+	}
+	if c := grid[0][0]; c.Rune != 'f' || c.Style != (Style{Attrs: Bold}) {
+		t.Errorf("Expected a bold 'f' but result was %+v", c)
+	}
+}
@@ -0,0 +1,81 @@
+package color
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Reset is the SGR sequence that resets all attributes.
+const Reset = "\x1b[0m"
+
+// ResetTerminal writes the SGR sequence that resets all attributes to w.
+func ResetTerminal(w io.Writer) error {
+	_, err := io.WriteString(w, Reset)
+	return err
+}
+
+// InstallResetOnExit makes a best effort to reset the attributes of the output of the
+// default Printer, standard output unless SetDefaultOutput was called, before the program
+// exits so that the terminal is not left colored. It only does so if the default Printer
+// has color output enabled.
+//
+// The terminal is reset when the program receives SIGINT or SIGTERM, after which the
+// signal is raised again without the handler so that the program exits as it would
+// have otherwise. If the program handles these signals itself, it receives them twice;
+// such programs should call ResetTerminal in their own handlers instead.
+//
+// There is no way to run code on os.Exit or on signals that cannot be caught such as
+// SIGKILL, so the terminal is not reset in those cases. The returned function resets
+// the terminal and removes the signal handler, and does nothing if it is called again.
+// Defer it in main to cover returning from main and panics in the main goroutine:
+//
+//	func main() {
+//		defer color.InstallResetOnExit()()
+//		...
+//	}
+func InstallResetOnExit() (reset func()) {
+	std := stdPrinter()
+	if !std.color {
+		return func() {}
+	}
+	var once sync.Once
+	resetOnce := func() {
+		once.Do(func() {
+			ResetTerminal(std.out)
+		})
+	}
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			resetOnce()
+			signal.Stop(sigs)
+			raise(sig)
+		case <-done:
+		}
+	}()
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+		})
+		resetOnce()
+	}
+}
+
+// raise sends sig to the current process, exiting if that is not possible.
+func raise(sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+}
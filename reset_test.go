@@ -0,0 +1,37 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResetTerminal(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := ResetTerminal(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.String() != "\x1b[0m" {
+		t.Errorf("Expected %q but result was %q", "\x1b[0m", b.String())
+	}
+}
+
+// Not parallel because it changes the default Printer.
+func TestInstallResetOnExit(t *testing.T) {
+	var b bytes.Buffer
+	stdMu.Lock()
+	prev := std
+	std = New(&b, true)
+	stdMu.Unlock()
+	defer func() {
+		stdMu.Lock()
+		defer stdMu.Unlock()
+		std = prev
+	}()
+	reset := InstallResetOnExit()
+	reset()
+	reset()
+	if b.String() != "\x1b[0m" {
+		t.Errorf("Expected %q but result was %q", "\x1b[0m", b.String())
+	}
+}
@@ -0,0 +1,111 @@
+package color
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxRingLine is the most bytes a RingPrinter keeps in a line.
+const maxRingLine = 64 << 10
+
+// RingPrinter is a Printer that keeps the last lines of its output in memory.
+type RingPrinter struct {
+	*Printer
+	rw *ringWriter
+}
+
+// NewRingPrinter creates a new RingPrinter that keeps the last lines lines of its output
+// in memory with color output enabled according to mode, see Lines and Colored. The
+// output is not a terminal, so PerformCheck disables color like DisableColor. Older lines
+// are dropped as new ones are written and a line longer than 64 KiB is broken, so the
+// memory used is bounded. A lines less than 1 keeps no lines. Like any Printer, it is safe
+// for concurrent use, and the clones of its Printer write to the same lines.
+func NewRingPrinter(mode Mode, lines int) *RingPrinter {
+	if lines < 0 {
+		lines = 0
+	}
+	rw := &ringWriter{lines: make([]string, 0, lines)}
+	return &RingPrinter{New(rw, mode.Color(rw)), rw}
+}
+
+// Lines returns the lines kept by rp from the oldest to the newest without their escape
+// sequences or line endings. A line that has not been ended yet is the last one and
+// counts as one of the lines kept, so there are never more than the lines given to
+// NewRingPrinter.
+func (rp *RingPrinter) Lines() []string {
+	lines := rp.Colored()
+	for i := range lines {
+		lines[i] = stripEscapes(lines[i])
+	}
+	return lines
+}
+
+// Colored is like Lines but keeps the escape sequences of the lines.
+func (rp *RingPrinter) Colored() []string {
+	return rp.rw.Lines()
+}
+
+// ringWriter keeps the last cap(lines) lines written to it.
+type ringWriter struct {
+	mu      sync.Mutex
+	lines   []string // ring of the ended lines
+	next    int      // index of the oldest line once lines is full
+	partial string   // line that has not been ended yet, shorter than maxRingLine
+}
+
+// Write splits p into lines, appending the first to the partial line.
+func (rw *ringWriter) Write(p []byte) (n int, err error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	s := rw.partial + string(p)
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 || i > maxRingLine {
+			if len(s) < maxRingLine {
+				break
+			}
+			// Break the line at a rune boundary unless it is not valid UTF-8.
+			i = maxRingLine
+			for i > maxRingLine-utf8.UTFMax && !utf8.RuneStart(s[i]) {
+				i--
+			}
+			rw.push(s[:i])
+			s = s[i:]
+			continue
+		}
+		rw.push(strings.TrimSuffix(s[:i], "\r"))
+		s = s[i+1:]
+	}
+	rw.partial = s
+	return len(p), nil
+}
+
+// push adds line to the ring, replacing the oldest line if it is full.
+func (rw *ringWriter) push(line string) {
+	switch {
+	case cap(rw.lines) == 0:
+	case len(rw.lines) < cap(rw.lines):
+		rw.lines = append(rw.lines, line)
+	default:
+		rw.lines[rw.next] = line
+		rw.next = (rw.next + 1) % len(rw.lines)
+	}
+}
+
+// Lines returns a copy of the lines from the oldest to the newest, followed by the
+// partial line if there is one, in place of the oldest line if the ring is full.
+func (rw *ringWriter) Lines() []string {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	lines := make([]string, 0, len(rw.lines)+1)
+	lines = append(lines, rw.lines[rw.next:]...)
+	lines = append(lines, rw.lines[:rw.next]...)
+	if rw.partial != "" && cap(rw.lines) > 0 {
+		if len(lines) == cap(rw.lines) {
+			lines = lines[1:]
+		}
+		lines = append(lines, rw.partial)
+	}
+	return lines
+}
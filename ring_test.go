@@ -0,0 +1,82 @@
+package color
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRingPrinter(t *testing.T) {
+	t.Parallel()
+	p := NewRingPrinter(EnableColor, 2)
+	p.Printf("%h[fgRed]one%r\n")
+	p.Printf("two\r\nthree\n")
+	p.Printf("%h[bold]four")
+	exp := []string{"three", "four"}
+	if lines := p.Lines(); !reflect.DeepEqual(lines, exp) {
+		t.Errorf("Expected %q but result was %q", exp, lines)
+	}
+	exp = []string{"three", Highlight("%h[bold]four")}
+	if lines := p.Colored(); !reflect.DeepEqual(lines, exp) {
+		t.Errorf("Expected %q but result was %q", exp, lines)
+	}
+	p.Clone().Println()
+	exp = []string{"three", "four"}
+	if lines := p.Lines(); !reflect.DeepEqual(lines, exp) {
+		t.Errorf("Expected %q but result was %q", exp, lines)
+	}
+
+	p = NewRingPrinter(PerformCheck, 1)
+	p.Printf("%h[fgRed]one%r\n")
+	if lines := p.Colored(); !reflect.DeepEqual(lines, []string{"one"}) {
+		t.Errorf("Expected %q but result was %q", []string{"one"}, lines)
+	}
+
+	p = NewRingPrinter(DisableColor, -1)
+	p.Print("one\ntwo")
+	if lines := p.Lines(); len(lines) != 0 {
+		t.Errorf("Expected no lines but result was %q", lines)
+	}
+}
+
+func TestRingPrinterLongLine(t *testing.T) {
+	t.Parallel()
+	p := NewRingPrinter(DisableColor, 3)
+	long := strings.Repeat("a", maxRingLine-1) + "世"
+	for i := 0; i < len(long); i += 1000 {
+		end := i + 1000
+		if end > len(long) {
+			end = len(long)
+		}
+		p.Print(long[i:end])
+	}
+	p.Print("b")
+	exp := []string{long[:maxRingLine-1], "世b"}
+	if lines := p.Lines(); !reflect.DeepEqual(lines, exp) {
+		t.Errorf("Expected %d lines with the last %q but result was %d lines", len(exp), exp[1], len(lines))
+	}
+}
+
+func TestRingPrinterConcurrent(t *testing.T) {
+	t.Parallel()
+	p := NewRingPrinter(DisableColor, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Println("line")
+		}()
+	}
+	wg.Wait()
+	lines := p.Lines()
+	if len(lines) != 10 {
+		t.Fatalf("Expected %d lines but result was %d", 10, len(lines))
+	}
+	for _, l := range lines {
+		if l != "line" {
+			t.Errorf("Expected %q but result was %q", "line", l)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package color
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Sanitize returns s without the escape sequences and control characters that could
+// change the state of a terminal. Exactly the following are removed:
+//
+//	CSI sequences: ESC '[' followed by any parameter bytes (0x30-0x3F), any intermediate
+//	bytes (0x20-0x2F) and a final byte (0x40-0x7E).
+//
+//	OSC, DCS, SOS, PM and APC strings: ESC followed by one of ']', 'P', 'X', '^' or '_'
+//	up to and including the terminating BEL (0x07) or ESC '\'.
+//
+//	Other escape sequences: ESC followed by any intermediate bytes (0x20-0x2F) and a
+//	final byte (0x30-0x7E).
+//
+//	An ESC (0x1B) that does not start a complete escape sequence.
+//
+//	The C0 control characters (0x00-0x1F) except for tab (0x09) and newline (0x0A),
+//	and DEL (0x7F).
+//
+//	The C1 control characters, U+0080-U+009F, and the bytes 0x80-0x9F when they are
+//	not part of valid UTF-8, because some terminals treat them as C1 control characters.
+func Sanitize(s string) string {
+	return sanitize(s, "")
+}
+
+// loneESC replaces an ESC that does not start a complete escape sequence in the
+// arguments sanitized for the SanitizeArgs flag.
+const loneESC = "^["
+
+// sanitize is the same as Sanitize but it replaces an ESC that does not start a complete
+// escape sequence with esc.
+func sanitize(s, esc string) string {
+	var buf bytes.Buffer
+	last := 0 // start of the bytes not yet written to buf
+	for i := 0; i < len(s); {
+		n := 0     // length of the bytes to remove
+		repl := "" // replaces the removed bytes
+		if s[i] == '\x1b' {
+			if n = escapeLen(s[i:]); n == 0 {
+				n, repl = 1, esc
+			}
+		} else if s[i] < utf8.RuneSelf {
+			if isControl(s[i]) {
+				n = 1
+			}
+		} else if r, size := utf8.DecodeRuneInString(s[i:]); (r >= 0x80 && r <= 0x9f) ||
+			(r == utf8.RuneError && size == 1 && s[i] <= 0x9f) {
+			n = size
+		} else {
+			i += size
+			continue
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+		buf.WriteString(s[last:i])
+		buf.WriteString(repl)
+		i += n
+		last = i
+	}
+	if last == 0 {
+		return s
+	}
+	buf.WriteString(s[last:])
+	return buf.String()
+}
+
+// isControl returns true if the ASCII character ch is a control character
+// that should be removed by Sanitize.
+func isControl(ch byte) bool {
+	return (ch < 0x20 && ch != '\t' && ch != '\n') || ch == 0x7f
+}
+
+// escapeLen returns the length of the escape sequence at the start of s.
+// It returns 0 if s does not start with a complete escape sequence.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		i := 2
+		for i < len(s) && s[i] >= 0x30 && s[i] <= 0x3f {
+			i++
+		}
+		for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+			i++
+		}
+		if i < len(s) && s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+		return 0
+	case ']', 'P', 'X', '^', '_':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+	}
+	i := 1
+	for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+		i++
+	}
+	if i < len(s) && s[i] >= 0x30 && s[i] <= 0x7e {
+		return i + 1
+	}
+	return 0
+}
+
+// sanitizeArgs replaces each argument in a with a sanitized version, with loneESC in
+// place of an ESC that does not start a complete escape sequence.
+// Formats are left alone as they are trusted and so are plain arguments.
+func sanitizeArgs(a []interface{}) {
+	for i, v := range a {
+		switch v := v.(type) {
+		case *Format:
+		case string:
+			a[i] = sanitize(v, loneESC)
+		default:
+			if !plainArg(v) {
+				a[i] = sanitizer{v}
+			}
+		}
+	}
+}
+
+// unsanitizeRawArgs restores the arguments in a that sanitizeArgs wrapped but that the
+// processed format uses as a width or precision or with %T or %p, which fmt handles
+// before calling the Format method of the wrapper. They write no text of their own.
+func unsanitizeRawArgs(format string, a []interface{}) {
+	for i, raw := range rawArgs(format, len(a)) {
+		if s, ok := a[i].(sanitizer); ok && raw {
+			a[i] = s.v
+		}
+	}
+}
+
+// plainArg returns true if fmt formats v without any text of its own, e.g. a number,
+// so that it needs no wrapping to be sanitized or escaped. Wrapping such a value would
+// break it as the width or precision of a '*' and with %T and %p, which fmt handles
+// before calling the Format method of the wrapper.
+func plainArg(v interface{}) bool {
+	switch v.(type) {
+	case fmt.Formatter, fmt.Stringer, fmt.GoStringer, error:
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr, reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128,
+		reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	case reflect.Ptr:
+		// fmt writes the value of a pointer to a composite value, e.g. &{foo}.
+		switch rv.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// sanitizer wraps a value so that it is sanitized when formatted by fmt.
+type sanitizer struct {
+	v interface{}
+}
+
+// Format formats s.v according to the verb and the flags in f and
+// then writes it sanitized to f like sanitizeArgs.
+func (s sanitizer) Format(f fmt.State, verb rune) {
+	io.WriteString(f, sanitize(fmt.Sprintf(directive(f, verb), s.v), loneESC))
+}
+
+// directive returns the fmt directive with the verb and the flags in f, e.g. "%-8.2f".
+func directive(f fmt.State, verb rune) string {
+	d := []byte{'%'}
+	for _, c := range "+-# 0" {
+		if f.Flag(int(c)) {
+			d = append(d, byte(c))
+		}
+	}
+	if w, ok := f.Width(); ok {
+		d = strconv.AppendInt(d, int64(w), 10)
+	}
+	if p, ok := f.Precision(); ok {
+		d = append(d, '.')
+		d = strconv.AppendInt(d, int64(p), 10)
+	}
+	return string(append(d, string(verb)...))
+}
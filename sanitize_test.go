@@ -0,0 +1,118 @@
+package color
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var sanitizeCases = map[string]string{
+	"foo":                       "foo",
+	"foo\tbar\n":                "foo\tbar\n",
+	"\x1b[31mred\x1b[0m":        "red",
+	"\x1b[?25lhidden":           "hidden",
+	"\x1b]0;title\abar":         "bar",
+	"\x1b]8;;http://x\x1b\\bar": "bar",
+	"\x1b(Bfoo":                 "foo",
+	"\x1bcfoo":                  "foo",
+	"foo\x1b":                   "foo",
+	"foo\x1b[31":                "foo[31",
+	"\x1b]0;title":              "]0;title",
+	"a\rb\bc\x00d\x7fe":         "abcde",
+	"a\u009b31mb":               "a31mb",
+	"a\x9b31mb":                 "a31mb",
+	"héllo, 世界":                 "héllo, 世界",
+	"\xff\xfe":                  "\xff\xfe",
+}
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+	for k, v := range sanitizeCases {
+		if r := Sanitize(k); r != v {
+			t.Errorf("Expected %q from %q but result was %q", v, k, r)
+		}
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string {
+	return string(s)
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(SanitizeArgs)
+	if p.Flags() != SanitizeArgs {
+		t.Errorf("Expected flags %d but result was %d", SanitizeArgs, p.Flags())
+	}
+	f := Prepare("%h[fgBlue]foo")
+	p.Printf("%h[fgRed]%s %v %5d %s %s%r", "\x1b[2Jbar", stringer("\x1b]0;x\abaz"), 42, errors.New("\x1b[1mqux"), f)
+	exp := Highlight("%h[fgRed]") + "bar baz    42 qux " + f.Get(true) + Highlight("%r")
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Print("\x1b[31mfoo", "bar")
+	exp = "foobar"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
+
+func TestSanitizeArgsPlain(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, false)
+	p.SetFlags(SanitizeArgs)
+	n := 7
+	p.Printf("[%*d] [%-*.*f] [%T] [%T] [%t] [%v]", 5, 42, 6, 1, 2.5, 42, "x", true, &n == nil)
+	if exp := "[   42] [2.5   ] [int] [string] [true] [false]"; b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printf("%p", &n)
+	if exp := fmt.Sprintf("%p", &n); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printf("%v", []string{"\x1b[1mfoo"})
+	if b.String() != "[foo]" {
+		t.Errorf("Expected %q but result was %q", "[foo]", b.String())
+	}
+	type pt struct{ x int }
+	s := []int{1}
+	b.Reset()
+	p.Printf("%T %p %v", pt{1}, s, pt{2})
+	if exp := fmt.Sprintf("%T %p %v", pt{1}, s, pt{2}); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printfp(Prepare("%T|%p|%v"), &pt{1}, s, struct{ s string }{"\x1b[1mfoo"})
+	if exp := fmt.Sprintf("%T|%p|{foo}", &pt{1}, s); b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	p.Printf("[%*d]", uint(3), 4)
+	if b.String() != "[  4]" {
+		t.Errorf("Expected %q but result was %q", "[  4]", b.String())
+	}
+}
+
+func TestSanitizeArgsLoneESC(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	p := New(&b, true)
+	p.SetFlags(SanitizeArgs)
+	p.Printf("%h[fgRed]%s%r %v\n", "foo\x1b[31", stringer("\x1b]0;bar"))
+	exp := Highlight("%h[fgRed]") + "foo^[[31" + Highlight("%r") + " ^[]0;bar\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	if s := Sanitize("foo\x1b[31"); s != "foo[31" {
+		t.Errorf("Expected %q but result was %q", "foo[31", s)
+	}
+}
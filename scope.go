@@ -0,0 +1,20 @@
+package color
+
+// Scope returns inner in the style of spec followed by a reset, so that the style cannot
+// carry over into whatever follows. The spec argument is the list of attributes as it
+// would appear between the brackets of the highlight verb, e.g. "fgRed+bold". The style
+// is set again after every reset in inner, so inner may be styled itself. If spec is
+// invalid, inner is returned plain and followed by the error, e.g. "%!h(BADATTR)".
+// Like Highlight, it always produces colored output unless the terminfo entry of the
+// terminal could not be loaded, in which case inner is returned as it is. Use the %{...} block verb instead
+// to scope a style within a format, see the package documentation.
+func Scope(spec, inner string) string {
+	if e := specError(spec); e != "" {
+		return inner + " " + e
+	}
+	if tiErr != nil {
+		return inner
+	}
+	start := Highlight(verb(spec))
+	return start + reapply(inner, start) + reset()
+}
@@ -0,0 +1,32 @@
+package color
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+	red := Highlight("%h[fgRed]")
+	tests := []struct {
+		spec, inner, exp string
+	}{
+		{"fgRed", "panic:", red + "panic:" + reset()},
+		{"fgRed", Highlight("a%h[bold]b%rc"), red + "a" + Highlight("%h[bold]") + "b" + reset() + red + "c" + reset()},
+		{"fgFoo", "panic:", "panic: %!h(BADATTR)"},
+	}
+	for _, tc := range tests {
+		if r := Scope(tc.spec, tc.inner); r != tc.exp {
+			t.Errorf("Expected %q but result was %q", tc.exp, r)
+		}
+	}
+}
+
+// Not parallel because it changes global state.
+func TestScopeNoTerminfo(t *testing.T) {
+	defer func(err error) { tiErr = err }(tiErr)
+	tiErr = errors.New("no terminfo")
+	if r := Scope("fgRed", "panic:"); r != "panic:" {
+		t.Errorf("Expected %q but result was %q", "panic:", r)
+	}
+}
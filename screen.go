@@ -0,0 +1,31 @@
+package color
+
+import "io"
+
+// The sequences that switch to and from the alternate screen buffer and show the cursor.
+const (
+	enterAltScreen = "\x1b[?1049h"
+	exitAltScreen  = "\x1b[?1049l"
+	showCursor     = "\x1b[?25h"
+)
+
+// AltScreen calls f with the terminal that w writes to switched to the alternate screen
+// buffer if enabled is true, which preserves the scrollback of the user while f draws
+// the screen. Afterwards the terminal is switched back and the cursor, which f might have
+// hidden, is shown again. The switch back is deferred, so it also happens if f panics.
+// If enabled is false, e.g. because color output is disabled, f is called without
+// writing anything. It returns the error from f or from writing the sequences.
+func AltScreen(w io.Writer, enabled bool, f func() error) (err error) {
+	if !enabled {
+		return f()
+	}
+	if _, err := io.WriteString(w, enterAltScreen); err != nil {
+		return err
+	}
+	defer func() {
+		if _, werr := io.WriteString(w, exitAltScreen+showCursor); err == nil {
+			err = werr
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,48 @@
+package color
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAltScreen(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	ferr := errors.New("foo")
+	err := AltScreen(&b, true, func() error {
+		b.WriteString("screen")
+		return ferr
+	})
+	if err != ferr {
+		t.Errorf("Expected %v but result was %v", ferr, err)
+	}
+	exp := enterAltScreen + "screen" + exitAltScreen + showCursor
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+	b.Reset()
+	AltScreen(&b, false, func() error {
+		b.WriteString("screen")
+		return nil
+	})
+	if b.String() != "screen" {
+		t.Errorf("Expected %q but result was %q", "screen", b.String())
+	}
+}
+
+func TestAltScreenPanic(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected the panic to propagate")
+		}
+		if exp := enterAltScreen + exitAltScreen + showCursor; b.String() != exp {
+			t.Errorf("Expected %q but result was %q", exp, b.String())
+		}
+	}()
+	AltScreen(&b, true, func() error {
+		panic("foo")
+	})
+}
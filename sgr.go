@@ -0,0 +1,195 @@
+package color
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// colorNames holds the names of the named colors in order of their integer values.
+var colorNames = [...]string{
+	"Black",
+	"Red",
+	"Green",
+	"Yellow",
+	"Blue",
+	"Magenta",
+	"Cyan",
+	"White",
+	"BrightBlack",
+	"BrightRed",
+	"BrightGreen",
+	"BrightYellow",
+	"BrightBlue",
+	"BrightMagenta",
+	"BrightCyan",
+	"BrightWhite",
+}
+
+// sgrModes holds the modes in the order they are written by CodesToVerb
+// along with the SGR codes that turn them on and off.
+var sgrModes = [...]struct {
+	name    string
+	code    int
+	offCode int
+}{
+	{"bold", 1, 22},
+	{"dim", 2, 22},
+	{"underline", 4, 24},
+	{"blink", 5, 25},
+	{"reverse", 7, 27},
+}
+
+// CodesToVerb returns the highlight verb that sets the same attributes as a SGR sequence
+// with codes as its parameters. For example, []int{1, 31} becomes "%h[fgRed+bold]".
+// 256 colors (38;5;n) become fgn and true colors (38;2;r;g;b) become fg#rrggbb.
+// 39 and 49 become fgDefault and bgDefault and the codes that turn modes off become
+// their off attributes, e.g. 24 becomes -underline and 22 becomes -bold, which turns off
+// dim as well. The attributes are written in a fixed order: reset, foreground,
+// background, the modes turned off and then the modes turned on, and where codes
+// override each other only the last one is kept. The result thus sets the same
+// attributes as codes but not necessarily in the same order.
+// An error naming the code is returned for any code the highlight verb cannot express.
+func CodesToVerb(codes []int) (string, error) {
+	if len(codes) == 0 {
+		return "", errors.New("color: no SGR codes")
+	}
+	var (
+		reset  bool
+		fg, bg string
+		modes  [len(sgrModes)]bool
+		offs   [len(sgrModes)]bool
+	)
+	for i := 0; i < len(codes); i++ {
+		switch c := codes[i]; {
+		case c == 0:
+			reset = true
+			fg, bg = "", ""
+			modes = [len(sgrModes)]bool{}
+			offs = [len(sgrModes)]bool{}
+		case c == 39:
+			fg = "fgDefault"
+		case c == 49:
+			bg = "bgDefault"
+		case c == 22 || c == 24 || c == 25 || c == 27:
+			// 22 turns off both bold and dim and is written as -bold.
+			j := -1
+			for k, m := range sgrModes {
+				if m.offCode == c {
+					modes[k] = false
+					if j == -1 {
+						j = k
+					}
+				}
+			}
+			offs[j] = true
+		case c >= 30 && c <= 37:
+			fg = "fg" + colorNames[c-30]
+		case c >= 90 && c <= 97:
+			fg = "fg" + colorNames[c-90+8]
+		case c >= 40 && c <= 47:
+			bg = "bg" + colorNames[c-40]
+		case c >= 100 && c <= 107:
+			bg = "bg" + colorNames[c-100+8]
+		case c == 38 || c == 48:
+			a, n, err := extendedColor(codes[i:])
+			if err != nil {
+				return "", err
+			}
+			if c == 38 {
+				fg = "fg" + a
+			} else {
+				bg = "bg" + a
+			}
+			i += n - 1
+		default:
+			j := 0
+			for j < len(sgrModes) && sgrModes[j].code != c {
+				j++
+			}
+			if j == len(sgrModes) {
+				return "", fmt.Errorf("color: unknown SGR code %d", c)
+			}
+			modes[j] = true
+			if sgrModes[j].offCode != 22 {
+				// Turning on bold does not undo the -bold that also turned off dim.
+				offs[j] = false
+			}
+		}
+	}
+	var attrs []string
+	if reset {
+		attrs = append(attrs, "reset")
+	}
+	if fg != "" {
+		attrs = append(attrs, fg)
+	}
+	if bg != "" {
+		attrs = append(attrs, bg)
+	}
+	for i, ok := range offs {
+		if ok {
+			attrs = append(attrs, "-"+sgrModes[i].name)
+		}
+	}
+	for i, ok := range modes {
+		if ok {
+			attrs = append(attrs, sgrModes[i].name)
+		}
+	}
+	if len(attrs) == 0 {
+		// Only possible if the codes undo each other, e.g. a reset.
+		return verb("reset"), nil
+	}
+	return verb(strings.Join(attrs, "+")), nil
+}
+
+// checkCodes returns an error if codes are not plausible SGR parameters.
+func checkCodes(codes []int) error {
+	if len(codes) == 0 {
+		return errors.New("color: no SGR codes")
+	}
+	for i := 0; i < len(codes); i++ {
+		switch c := codes[i]; {
+		case c == 38 || c == 48 || c == 58:
+			_, n, err := extendedColor(codes[i:])
+			if err != nil {
+				return err
+			}
+			i += n - 1
+		case c < 0 || c > 107:
+			return fmt.Errorf("color: bad SGR code %d", c)
+		}
+	}
+	return nil
+}
+
+// extendedColor parses the extended color run at the start of codes, e.g. 38;5;n.
+// It returns the attribute without its "fg" or "bg" prefix and the number of codes in the run.
+func extendedColor(codes []int) (string, int, error) {
+	if len(codes) < 2 {
+		return "", 0, fmt.Errorf("color: SGR code %d is missing its color", codes[0])
+	}
+	switch codes[1] {
+	case 5:
+		if len(codes) < 3 {
+			return "", 0, fmt.Errorf("color: SGR code %d;5 is missing its color index", codes[0])
+		}
+		if codes[2] < 0 || codes[2] > 255 {
+			return "", 0, fmt.Errorf("color: bad color index %d in SGR code %d;5", codes[2], codes[0])
+		}
+		return strconv.Itoa(codes[2]), 3, nil
+	case 2:
+		if len(codes) < 5 {
+			return "", 0, fmt.Errorf("color: SGR code %d;2 is missing its color components", codes[0])
+		}
+		for _, c := range codes[2:5] {
+			if c < 0 || c > 255 {
+				return "", 0, fmt.Errorf("color: bad color component %d in SGR code %d;2", c, codes[0])
+			}
+		}
+		return fmt.Sprintf("#%02x%02x%02x", codes[2], codes[3], codes[4]), 5, nil
+	}
+	return "", 0, fmt.Errorf("color: unknown SGR code %d;%d", codes[0], codes[1])
+}
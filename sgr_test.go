@@ -0,0 +1,65 @@
+package color
+
+import "testing"
+
+var codesToVerbs = []struct {
+	codes []int
+	verb  string
+}{
+	{[]int{1, 31}, "%h[fgRed+bold]"},
+	{[]int{0}, "%h[reset]"},
+	{[]int{0, 44, 4}, "%h[reset+bgBlue+underline]"},
+	{[]int{91, 101}, "%h[fgBrightRed+bgBrightRed]"},
+	{[]int{7, 5, 2}, "%h[dim+blink+reverse]"},
+	{[]int{31, 32}, "%h[fgGreen]"},
+	{[]int{31, 0, 1}, "%h[reset+bold]"},
+	{[]int{38, 5, 208, 48, 5, 0}, "%h[fg208+bg0]"},
+	{[]int{38, 2, 255, 0, 16, 1}, "%h[fg#ff0010+bold]"},
+	{[]int{48, 2, 1, 2, 3}, "%h[bg#010203]"},
+	{[]int{31, 39, 49}, "%h[fgDefault+bgDefault]"},
+	{[]int{4, 24, 27}, "%h[-underline+-reverse]"},
+	{[]int{1, 2, 22}, "%h[-bold]"},
+	{[]int{22, 2, 25, 5}, "%h[-bold+dim+blink]"},
+}
+
+func TestCodesToVerb(t *testing.T) {
+	t.Parallel()
+	for _, c := range codesToVerbs {
+		r, err := CodesToVerb(c.codes)
+		if err != nil {
+			t.Errorf("Unexpected error from %v: %v", c.codes, err)
+		} else if r != c.verb {
+			t.Errorf("Expected %q from %v but result was %q", c.verb, c.codes, r)
+		}
+	}
+}
+
+var badCodes = [][]int{
+	nil,
+	{3},
+	{1, 256},
+	{38},
+	{38, 5},
+	{38, 5, 256},
+	{48, 2, 1, 2},
+	{48, 2, 1, -2, 3},
+	{38, 3, 1},
+}
+
+func TestCodesToVerbErrors(t *testing.T) {
+	t.Parallel()
+	for _, codes := range badCodes {
+		if r, err := CodesToVerb(codes); err == nil {
+			t.Errorf("Expected error from %v but result was %q", codes, r)
+		}
+	}
+}
+
+func TestCodesToVerbRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, c := range codesToVerbs {
+		if err := checkSpec(c.verb[len("%h[") : len(c.verb)-1]); err != nil {
+			t.Errorf("Expected %q to be valid: %v", c.verb, err)
+		}
+	}
+}
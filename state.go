@@ -0,0 +1,32 @@
+package color
+
+import "fmt"
+
+// SprintfState is like SprintfContext without a context but it assumes that the terminal
+// is already in the style prev and it returns the style the terminal is in after the
+// output, so that the output can be produced in pieces that continue each other:
+//
+//	s, st := color.SprintfState(color.Style{}, "%h[fgRed]foo")
+//	s2, st := color.SprintfState(st, "%h[fgRed+bold]bar%h[bgBlue]")
+//
+// Each highlight verb is replaced with only the SGR sequence that changes the style from
+// what it was before the verb, so the %h[fgRed] in the second call only turns on bold.
+// A verb with attributes that a Style cannot represent, such as raw and bgFill, is
+// written unchanged and its effect is not fully reflected in the returned style.
+// Neither is the effect of any escape sequences in the arguments, including Formats.
+// The preference of the default Printer determines whether color output is
+// enabled, the style is returned either way.
+func SprintfState(prev Style, format string, a ...interface{}) (string, Style) {
+	return sprintfState(stdPrinter().color, prev, format, a)
+}
+
+// sprintfState is SprintfState with the color preference as an argument.
+func sprintfState(color bool, prev Style, format string, a []interface{}) (string, Style) {
+	hl := newHighlighter(format, color)
+	defer hl.free()
+	st := prev
+	hl.st = &st
+	format = hl.run()
+	ExpandFormats(color, a)
+	return fmt.Sprintf(format, a...), st
+}
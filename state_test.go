@@ -0,0 +1,66 @@
+package color
+
+import "testing"
+
+var states = []struct {
+	prev   Style
+	format string
+	out    string
+	st     Style
+}{
+	{Style{}, "foo", "foo", Style{}},
+	{Style{}, "%h[fgRed]foo", "\x1b[31mfoo", Style{Fg: red}},
+	{Style{Fg: red}, "%h[fgRed+bold]foo", "\x1b[1mfoo", Style{Fg: red, Attrs: Bold}},
+	{Style{Fg: red, Attrs: Bold}, "foo%rbar", "foo" + reset() + "bar", Style{}},
+	{Style{Fg: red}, "%h[reset+fg#010203+bgDefault]", "\x1b[38;2;1;2;3m", Style{Fg: TrueColor(1, 2, 3), Bg: DefaultColor}},
+	{Style{}, "%h[fg208+underline]", "\x1b[4;38;5;208m", Style{Fg: PaletteColor(208), Attrs: Underline}},
+	{Style{Attrs: Underline}, "%h[reset]", Reset, Style{}},
+	{Style{}, "%h[bold+raw=3]", Highlight("%h[bold+raw=3]"), Style{Attrs: Bold}},
+	{Style{}, "%h[bgFill1]", Highlight("%h[bgFill1]"), Style{Bg: PaletteColor(1)}},
+	{Style{Fg: red}, "%h[fgRed+bell]", "\a", Style{Fg: red}},
+	{Style{Attrs: Bold | Underline}, "%h[fgRed+-bold]", "\x1b[22;31m", Style{Fg: red, Attrs: Underline}},
+	{Style{Attrs: Dim | Blink}, "%h[-bold+-blink]", Reset, Style{}},
+	{Style{Attrs: Bold}, "%{fgRed}a%}b", "\x1b[31ma\x1b[39mb", Style{Attrs: Bold}},
+	{Style{}, "%{bold}a%{underline}b%}c%}", "\x1b[1ma\x1b[4mb\x1b[24mc" + Reset, Style{}},
+}
+
+func TestSprintfState(t *testing.T) {
+	t.Parallel()
+	if tiErr != nil {
+		t.Skip("no terminfo")
+	}
+	for _, tc := range states {
+		out, st := sprintfState(true, tc.prev, tc.format, nil)
+		if out != tc.out {
+			t.Errorf("Expected %q from %q but result was %q", tc.out, tc.format, out)
+		}
+		if st != tc.st {
+			t.Errorf("Expected %+v from %q but result was %+v", tc.st, tc.format, st)
+		}
+	}
+}
+
+func TestSprintfStateArgs(t *testing.T) {
+	t.Parallel()
+	out, st := sprintfState(false, Style{}, "%h[fgRed]%s %v", []interface{}{"foo", Prepare("%h[bold]bar")})
+	if out != "foo bar" {
+		t.Errorf("Expected %q but result was %q", "foo bar", out)
+	}
+	if st != (Style{Fg: red}) {
+		t.Errorf("Expected %+v but result was %+v", Style{Fg: red}, st)
+	}
+}
+
+func TestSprintfStateStyles(t *testing.T) {
+	t.Parallel()
+	if err := RegisterStyle("stateDanger", "fgRed+bold"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := RegisterStyle("stateLoud", "stateDanger+underline"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := Style{Fg: red, Bg: blue, Attrs: Bold | Underline}
+	if _, st := sprintfState(true, Style{Bg: blue}, "%h[stateLoud]", nil); st != exp {
+		t.Errorf("Expected %+v but result was %+v", exp, st)
+	}
+}
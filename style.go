@@ -0,0 +1,487 @@
+package color
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Attr is a set of mode attributes.
+type Attr uint8
+
+// The mode attributes of a Style.
+const (
+	Bold Attr = 1 << iota
+	Dim
+	Underline
+	Blink
+	Reverse
+)
+
+// attrCodes holds the SGR codes that turn the mode attributes on and off
+// in the order of their bits.
+var attrCodes = [...]struct {
+	on, off int
+}{
+	{1, 22},
+	{2, 22},
+	{4, 24},
+	{5, 25},
+	{7, 27},
+}
+
+// colorKind is the type of a Color.
+type colorKind uint8
+
+const (
+	kindNone    colorKind = iota // no color set
+	kindDefault                  // the terminal default color
+	kindIndex                    // 256 color palette index
+	kindRGB                      // true color
+)
+
+// Color is a foreground or background color of a Style.
+// The zero value is no color at all, which looks like the terminal default
+// but is unset rather than set to the default when styles are merged.
+type Color struct {
+	kind    colorKind
+	n       uint8 // palette index
+	r, g, b uint8 // true color components
+}
+
+// DefaultColor is the terminal default color, set with the 39 and 49 SGR codes.
+var DefaultColor = Color{kind: kindDefault}
+
+// PaletteColor returns the color at index n in the 256 color palette.
+// The first 16 indexes are the named colors from Black to BrightWhite.
+func PaletteColor(n uint8) Color {
+	return Color{kind: kindIndex, n: n}
+}
+
+// TrueColor returns the true color with the red, green and blue components r, g and b.
+func TrueColor(r, g, b uint8) Color {
+	return Color{kind: kindRGB, r: r, g: g, b: b}
+}
+
+// Index returns the palette index of c and true if c is a 256 color.
+func (c Color) Index() (n uint8, ok bool) {
+	return c.n, c.kind == kindIndex
+}
+
+// RGB returns the components of c and true if c is a true color.
+func (c Color) RGB() (RGB, bool) {
+	return RGB{c.r, c.g, c.b}, c.kind == kindRGB
+}
+
+// isDefault returns true if c looks like the terminal default.
+func (c Color) isDefault() bool {
+	return c.kind == kindNone || c.kind == kindDefault
+}
+
+// appendCodes appends the SGR codes that set the foreground to c, or the background
+// if fg is false, to codes.
+func (c Color) appendCodes(codes []int, fg bool) []int {
+	base := 30
+	if !fg {
+		base = 40
+	}
+	switch {
+	case c.isDefault():
+		return append(codes, base+9)
+	case c.kind == kindRGB:
+		return append(codes, base+8, 2, int(c.r), int(c.g), int(c.b))
+	case c.n < 8:
+		return append(codes, base+int(c.n))
+	case c.n < 16:
+		return append(codes, base+60+int(c.n)-8)
+	}
+	return append(codes, base+8, 5, int(c.n))
+}
+
+// Style is a set of attributes, e.g. the state of a terminal after a highlight verb.
+// Each attribute is unset, set to the default or set to a value: a color is unset if it
+// is the zero Color and set to the default if it is DefaultColor, a mode attribute is set
+// if it is in Attrs and set to the default, turned off, if it is only in Off. Unset and
+// default attributes look the same, they only differ when styles are merged.
+type Style struct {
+	Fg, Bg Color // foreground and background colors
+	Attrs  Attr  // mode attributes
+	Off    Attr  // mode attributes explicitly turned off, see Merge
+}
+
+// Diff returns the SGR sequence that changes a terminal from the state of prev to the
+// state of s. Only the attributes which differ are changed: dropped mode attributes
+// are turned off and new or changed ones are set. It returns an empty string if the
+// styles look the same. For example, the diff of {Fg: red, Attrs: Bold} from {Fg: red}
+// is only the SGR sequence that turns on bold.
+func (s Style) Diff(prev Style) string {
+	if s.isDefault() {
+		if prev.isDefault() {
+			return ""
+		}
+		// A reset is the shortest way back to the default.
+		return Reset
+	}
+	var codes []int
+	// Turning off bold also turns off dim and vice versa, so they
+	// might have to be turned on again.
+	off := prev.Attrs &^ s.Attrs
+	on := s.Attrs &^ prev.Attrs
+	if off&(Bold|Dim) != 0 {
+		on |= s.Attrs & (Bold | Dim)
+	}
+	for i, c := range attrCodes {
+		a := Attr(1 << uint(i))
+		if off&a != 0 && (i != 1 || off&Bold == 0) {
+			codes = append(codes, c.off)
+		}
+	}
+	for i, c := range attrCodes {
+		if on&Attr(1<<uint(i)) != 0 {
+			codes = append(codes, c.on)
+		}
+	}
+	if !s.Fg.same(prev.Fg) {
+		codes = s.Fg.appendCodes(codes, true)
+	}
+	if !s.Bg.same(prev.Bg) {
+		codes = s.Bg.appendCodes(codes, false)
+	}
+	return sgr(codes)
+}
+
+// ParseStyle returns the style a terminal in the default style is in after the
+// attributes in spec, e.g. "fgRed+bold". Attributes that a Style cannot represent,
+// such as raw and ulcolor, are ignored. See SprintfState.
+func ParseStyle(spec string) (Style, error) {
+	if err := checkSpec(spec); err != nil {
+		return Style{}, err
+	}
+	_, st := sprintfState(false, Style{}, verb(spec), nil)
+	return st, nil
+}
+
+// Sprint returns the result of fmt.Sprint with its arguments wrapped in the SGR sequence
+// that sets s and a reset. The arguments are returned plain if s is the default style.
+func (s Style) Sprint(a ...interface{}) string {
+	start := s.Diff(Style{})
+	if start == "" {
+		return fmt.Sprint(a...)
+	}
+	return start + fmt.Sprint(a...) + Reset
+}
+
+// WithoutColor returns s without its foreground and background colors,
+// keeping only its mode attributes.
+func (s Style) WithoutColor() Style {
+	return Style{Attrs: s.Attrs, Off: s.Off}
+}
+
+// ColorsOnly returns s without its mode attributes,
+// keeping only its foreground and background colors.
+func (s Style) ColorsOnly() Style {
+	return Style{Fg: s.Fg, Bg: s.Bg}
+}
+
+// Merge returns s with the attributes set in override, including those set to the
+// default, replacing its own. The attributes unset in override are kept from s.
+// For example, {Fg: red, Attrs: Bold} merged with {Attrs: Underline} is
+// {Fg: red, Attrs: Bold|Underline}, but merged with {Fg: DefaultColor, Off: Bold}
+// it is {Fg: DefaultColor, Off: Bold}. A mode attribute in both Attrs and Off
+// of override is set.
+func (s Style) Merge(override Style) Style {
+	if override.Fg.kind != kindNone {
+		s.Fg = override.Fg
+	}
+	if override.Bg.kind != kindNone {
+		s.Bg = override.Bg
+	}
+	off := override.Off &^ override.Attrs
+	s.Attrs = s.Attrs&^off | override.Attrs
+	s.Off = s.Off&^override.Attrs | off
+	return s
+}
+
+// Equal returns true if s and other set, unset and turn off the same attributes, which
+// is when their String methods return the same spec. Colors are only equal in the same
+// form: a named color is its palette index, so {Fg: PaletteColor(1)} is both %h[fgRed]
+// and %h[fg1], but a palette color never equals a true color as the palette is up to
+// the terminal. Unset attributes differ from those set to the default, as in Merge.
+func (s Style) Equal(other Style) bool {
+	return s.Fg == other.Fg && s.Bg == other.Bg && s.Attrs == other.Attrs &&
+		s.Off&^s.Attrs == other.Off&^other.Attrs
+}
+
+// String returns the canonical spec of s, e.g. "fgRed+bg208+bold+underline", which is
+// the same for styles that are Equal. The foreground and background colors come first
+// unless they are unset, written as a name for the first 16 palette indexes, as
+// an index for the rest, as #rrggbb for true colors and as Default for the default
+// color. They are followed by the mode attributes that are set and then by those that
+// are turned off, e.g. "-bold", each in alphabetical order. It returns an empty string
+// for the zero Style.
+func (s Style) String() string {
+	var tokens []string
+	if c := s.Fg.spec(); c != "" {
+		tokens = append(tokens, "fg"+c)
+	}
+	if c := s.Bg.spec(); c != "" {
+		tokens = append(tokens, "bg"+c)
+	}
+	var on, off []string
+	for name, a := range modeAttrs {
+		if s.Attrs&a != 0 {
+			on = append(on, name)
+		} else if s.Off&a != 0 {
+			off = append(off, "-"+name)
+		}
+	}
+	sort.Strings(on)
+	sort.Strings(off)
+	tokens = append(append(tokens, on...), off...)
+	return strings.Join(tokens, "+")
+}
+
+// spec returns c as it appears in a color attribute after "fg" or "bg", e.g. "Red" or
+// "#ff0000". It returns an empty string if c is unset.
+func (c Color) spec() string {
+	switch c.kind {
+	case kindDefault:
+		return "Default"
+	case kindRGB:
+		return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+	case kindIndex:
+		if int(c.n) < len(colorNames) {
+			return colorNames[c.n]
+		}
+		return strconv.Itoa(int(c.n))
+	}
+	return ""
+}
+
+// Lighten returns s with its foreground color moved toward white by amount, a fraction
+// from 0 to 1 that is clamped to that range. See Color.Lighten.
+func (s Style) Lighten(amount float64) Style {
+	s.Fg = s.Fg.Lighten(amount)
+	return s
+}
+
+// Darken returns s with its foreground color moved toward black by amount, a fraction
+// from 0 to 1 that is clamped to that range. See Color.Darken.
+func (s Style) Darken(amount float64) Style {
+	s.Fg = s.Fg.Darken(amount)
+	return s
+}
+
+// LightenBg is like Lighten but moves the background color instead.
+func (s Style) LightenBg(amount float64) Style {
+	s.Bg = s.Bg.Lighten(amount)
+	return s
+}
+
+// DarkenBg is like Darken but moves the background color instead.
+func (s Style) DarkenBg(amount float64) Style {
+	s.Bg = s.Bg.Darken(amount)
+	return s
+}
+
+// Lighten returns c moved toward white by amount, a fraction from 0 to 1 that is clamped
+// to that range, as a true color. A palette color is converted with the colors of the
+// xterm palette first. The default color is returned unchanged as its color is unknown.
+// For example, an amount of 0.5 is halfway between c and white.
+func (c Color) Lighten(amount float64) Color {
+	return c.mix(255, amount)
+}
+
+// Darken returns c moved toward black by amount like Lighten moves it toward white.
+func (c Color) Darken(amount float64) Color {
+	return c.mix(0, amount)
+}
+
+// mix returns c moved toward the gray v by amount.
+func (c Color) mix(v uint8, amount float64) Color {
+	var rgb RGB
+	switch c.kind {
+	case kindIndex:
+		rgb = RGBForIndex(c.n)
+	case kindRGB:
+		rgb = RGB{c.r, c.g, c.b}
+	default:
+		return c
+	}
+	if amount < 0 {
+		amount = 0
+	} else if amount > 1 {
+		amount = 1
+	}
+	mix := func(x uint8) uint8 {
+		return uint8(float64(x) + (float64(v)-float64(x))*amount + 0.5)
+	}
+	return TrueColor(mix(rgb.R), mix(rgb.G), mix(rgb.B))
+}
+
+// Case pairs a predicate with the style Conditional returns if it matches.
+// Create one with When.
+type Case struct {
+	pred  func(v interface{}) bool
+	style Style
+}
+
+// When returns a Case that matches the values for which pred returns true.
+func When(pred func(v interface{}) bool, s Style) Case {
+	return Case{pred, s}
+}
+
+// Conditional returns the style of the first case that matches v, or the default style
+// if none do. For example, to print negative numbers in red:
+//
+//	color.Conditional(n, color.When(isNegative, color.Style{Fg: color.PaletteColor(1)})).Sprint(n)
+func Conditional(v interface{}, cases ...Case) Style {
+	for _, c := range cases {
+		if c.pred(v) {
+			return c.style
+		}
+	}
+	return Style{}
+}
+
+// isDefault returns true if s looks like the terminal default.
+func (s Style) isDefault() bool {
+	return s.Fg.isDefault() && s.Bg.isDefault() && s.Attrs == 0
+}
+
+// same returns true if c and c2 look the same.
+func (c Color) same(c2 Color) bool {
+	if c.isDefault() {
+		return c2.isDefault()
+	}
+	return c == c2
+}
+
+// sgr returns the SGR sequence with codes as its parameters.
+// It returns an empty string if there are no codes.
+func sgr(codes []int) string {
+	if len(codes) == 0 {
+		return ""
+	}
+	b := make([]byte, 0, 2+len(codes)*4)
+	b = append(b, "\x1b["...)
+	for i, c := range codes {
+		if i > 0 {
+			b = append(b, ';')
+		}
+		b = strconv.AppendInt(b, int64(c), 10)
+	}
+	return string(append(b, 'm'))
+}
+
+// apply returns s changed by the SGR sequence with the parameters params, e.g. "1;31".
+// Unknown parameters are ignored.
+func (s Style) apply(params string) Style {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		c := codes[i]
+		if j := strings.IndexByte(c, ':'); j != -1 {
+			// Sub parameters such as 4:3 for a curly underline.
+			switch c[:j] {
+			case "4":
+				if c[j+1:] != "0" {
+					s.Attrs |= Underline
+				} else {
+					s.Attrs &^= Underline
+				}
+			case "38", "48":
+				// Colors such as 38:5:208 and 38:2::255:0:0, with or without
+				// the empty color space.
+				sub := strings.Split(c, ":")
+				if len(sub) == 6 && sub[1] == "2" {
+					sub = append(sub[:2], sub[3:]...)
+				}
+				if col, _ := sgrColor(sub, 0); c[:j] == "38" {
+					s.Fg = col
+				} else {
+					s.Bg = col
+				}
+			}
+			continue
+		}
+		n, err := strconv.Atoi(c)
+		if c == "" {
+			n, err = 0, nil
+		}
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			s = Style{}
+		case n == 22:
+			s.Attrs &^= Bold | Dim
+		case n == 24 || n == 25 || n == 27:
+			for k, ac := range attrCodes {
+				if ac.off == n {
+					s.Attrs &^= Attr(1 << uint(k))
+				}
+			}
+		case n >= 30 && n <= 37:
+			s.Fg = PaletteColor(uint8(n - 30))
+		case n >= 40 && n <= 47:
+			s.Bg = PaletteColor(uint8(n - 40))
+		case n >= 90 && n <= 97:
+			s.Fg = PaletteColor(uint8(n - 90 + 8))
+		case n >= 100 && n <= 107:
+			s.Bg = PaletteColor(uint8(n - 100 + 8))
+		case n == 39:
+			s.Fg = DefaultColor
+		case n == 49:
+			s.Bg = DefaultColor
+		case n == 38 || n == 48 || n == 58:
+			var c Color
+			c, i = sgrColor(codes, i)
+			if n == 38 {
+				s.Fg = c
+			} else if n == 48 {
+				s.Bg = c
+			}
+		default:
+			for k, ac := range attrCodes {
+				if ac.on == n {
+					s.Attrs |= Attr(1 << uint(k))
+				}
+			}
+		}
+	}
+	return s
+}
+
+// sgrColor returns the color of the extended color code at codes[i], followed by
+// 5;n or 2;r;g;b, and the index of its last parameter. The color is the default color
+// if the parameters are invalid.
+func sgrColor(codes []string, i int) (Color, int) {
+	arg := func(j int) (uint8, bool) {
+		if j >= len(codes) {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(codes[j], 10, 8)
+		return uint8(v), err == nil
+	}
+	if i+1 >= len(codes) {
+		return DefaultColor, i
+	}
+	switch codes[i+1] {
+	case "5":
+		if n, ok := arg(i + 2); ok {
+			return PaletteColor(n), i + 2
+		}
+		return DefaultColor, i + 2
+	case "2":
+		r, ok := arg(i + 2)
+		g, ok2 := arg(i + 3)
+		b, ok3 := arg(i + 4)
+		if ok && ok2 && ok3 {
+			return TrueColor(r, g, b), i + 4
+		}
+		return DefaultColor, i + 4
+	}
+	return DefaultColor, i + 1
+}
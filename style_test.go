@@ -0,0 +1,261 @@
+package color
+
+import "testing"
+
+var (
+	red   = PaletteColor(1)
+	blue  = PaletteColor(4)
+	pink  = TrueColor(255, 0, 128)
+	dark  = PaletteColor(236)
+	light = PaletteColor(9)
+)
+
+var diffs = []struct {
+	s, prev Style
+	exp     string
+}{
+	{Style{Fg: red, Attrs: Bold}, Style{Fg: red}, "\x1b[1m"},
+	{Style{Fg: red}, Style{Fg: red, Attrs: Bold}, "\x1b[22m"},
+	{Style{Fg: red}, Style{Fg: red}, ""},
+	{Style{}, Style{}, ""},
+	{Style{Fg: DefaultColor}, Style{}, ""},
+	{Style{}, Style{Fg: red, Attrs: Underline}, "\x1b[0m"},
+	{Style{Fg: blue}, Style{Fg: red}, "\x1b[34m"},
+	{Style{Fg: red}, Style{Fg: red, Bg: blue}, "\x1b[49m"},
+	{Style{Fg: light, Bg: dark}, Style{}, "\x1b[91;48;5;236m"},
+	{Style{Bg: pink, Attrs: Blink}, Style{Attrs: Reverse}, "\x1b[27;5;48;2;255;0;128m"},
+	{Style{Attrs: Dim}, Style{Attrs: Bold | Dim}, "\x1b[22;2m"},
+	{Style{Attrs: Dim | Underline}, Style{Attrs: Bold}, "\x1b[22;2;4m"},
+	{Style{Fg: red, Attrs: Bold}, Style{Attrs: Dim | Underline}, "\x1b[22;24;1;31m"},
+}
+
+func TestStyleDiff(t *testing.T) {
+	t.Parallel()
+	for _, d := range diffs {
+		if r := d.s.Diff(d.prev); r != d.exp {
+			t.Errorf("Expected %q from %+v diffed from %+v but result was %q", d.exp, d.s, d.prev, r)
+		}
+	}
+}
+
+func TestSprint(t *testing.T) {
+	t.Parallel()
+	if r := (Style{Fg: red, Attrs: Bold}).Sprint("foo", 1); r != "\x1b[1;31mfoo1\x1b[0m" {
+		t.Errorf("Expected %q but result was %q", "\x1b[1;31mfoo1\x1b[0m", r)
+	}
+	if r := (Style{Fg: DefaultColor}).Sprint("foo"); r != "foo" {
+		t.Errorf("Expected %q but result was %q", "foo", r)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	t.Parallel()
+	negative := When(func(v interface{}) bool { return v.(int) < 0 }, Style{Fg: red})
+	zero := When(func(v interface{}) bool { return v.(int) == 0 }, Style{Attrs: Dim})
+	small := When(func(v interface{}) bool { return v.(int) < 10 }, Style{Fg: blue})
+	conds := map[int]Style{
+		-5: {Fg: red},
+		0:  {Attrs: Dim},
+		5:  {Fg: blue},
+		50: {},
+	}
+	for k, v := range conds {
+		if r := Conditional(k, negative, zero, small); r != v {
+			t.Errorf("Expected %+v from %d but result was %+v", v, k, r)
+		}
+	}
+	if r := Conditional(1); r != (Style{}) {
+		t.Errorf("Expected %+v but result was %+v", Style{}, r)
+	}
+}
+
+var parsedStyles = []struct {
+	spec string
+	st   Style
+	err  bool
+}{
+	{"fgRed+bold", Style{Fg: red, Attrs: Bold}, false},
+	{"bg#ff0080+underline+dim", Style{Bg: pink, Attrs: Underline | Dim}, false},
+	{"fg236+bgDefault", Style{Fg: dark, Bg: DefaultColor}, false},
+	{"bold+reset+fgBlue", Style{Fg: blue}, false},
+	{"fgRed+raw=3", Style{Fg: red}, false},
+	{"fgRedd", Style{}, true},
+	{"", Style{}, true},
+}
+
+func TestParseStyle(t *testing.T) {
+	t.Parallel()
+	for _, tc := range parsedStyles {
+		st, err := ParseStyle(tc.spec)
+		if (err != nil) != tc.err {
+			t.Errorf("Expected error %v from %q but result was %v", tc.err, tc.spec, err)
+		}
+		if st != tc.st {
+			t.Errorf("Expected %+v from %q but result was %+v", tc.st, tc.spec, st)
+		}
+	}
+}
+
+func TestColorAccessors(t *testing.T) {
+	t.Parallel()
+	if n, ok := dark.Index(); !ok || n != 236 {
+		t.Errorf("Expected 236 but result was %d, %v", n, ok)
+	}
+	if _, ok := pink.Index(); ok {
+		t.Errorf("Expected a true color not to have an index")
+	}
+	if c, ok := pink.RGB(); !ok || c != (RGB{255, 0, 128}) {
+		t.Errorf("Expected %v but result was %v, %v", RGB{255, 0, 128}, c, ok)
+	}
+	if _, ok := DefaultColor.RGB(); ok {
+		t.Errorf("Expected the default color not to be a true color")
+	}
+}
+
+var applied = []struct {
+	prev   Style
+	params string
+	exp    Style
+}{
+	{Style{}, "1;31", Style{Fg: red, Attrs: Bold}},
+	{Style{Fg: red, Attrs: Bold | Dim}, "22;44", Style{Fg: red, Bg: blue}},
+	{Style{Fg: red}, "", Style{}},
+	{Style{}, "38;5;236;48;2;255;0;128", Style{Fg: dark, Bg: pink}},
+	{Style{}, "91;4:3", Style{Fg: light, Attrs: Underline}},
+	{Style{Fg: red, Attrs: Underline | Reverse}, "39;24;27;58;5;1;5", Style{Fg: DefaultColor, Attrs: Blink}},
+}
+
+func TestStyleApply(t *testing.T) {
+	t.Parallel()
+	for _, tc := range applied {
+		if r := tc.prev.apply(tc.params); r != tc.exp {
+			t.Errorf("Expected %+v from %q but result was %+v", tc.exp, tc.params, r)
+		}
+	}
+}
+
+func TestWithoutColor(t *testing.T) {
+	t.Parallel()
+	st := Style{Fg: red, Bg: blue, Attrs: Bold | Underline}
+	if r, exp := st.WithoutColor().Sprint("x"), "\x1b[1;4mx"+Reset; r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r, exp := st.ColorsOnly().Sprint("x"), "\x1b[31;44mx"+Reset; r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r := (Style{Fg: red}).WithoutColor().Sprint("x"); r != "x" {
+		t.Errorf("Expected %q but result was %q", "x", r)
+	}
+}
+
+func TestLightenDarken(t *testing.T) {
+	t.Parallel()
+	c := TrueColor(200, 100, 0)
+	tests := []struct {
+		r, exp Color
+	}{
+		{c.Darken(0.5), TrueColor(100, 50, 0)},
+		{c.Lighten(0.5), TrueColor(228, 178, 128)},
+		{c.Darken(2), TrueColor(0, 0, 0)},
+		{c.Lighten(-1), c},
+		{red.Darken(0.2), TrueColor(164, 0, 0)},
+		{DefaultColor.Lighten(0.5), DefaultColor},
+	}
+	for _, tc := range tests {
+		if tc.r != tc.exp {
+			t.Errorf("Expected %+v but result was %+v", tc.exp, tc.r)
+		}
+	}
+	st := Style{Fg: c, Bg: blue, Attrs: Bold}.Darken(0.5)
+	if exp := (Style{Fg: TrueColor(100, 50, 0), Bg: blue, Attrs: Bold}); st != exp {
+		t.Errorf("Expected %+v but result was %+v", exp, st)
+	}
+	st = Style{Fg: blue, Bg: c}.DarkenBg(0.5)
+	if exp := (Style{Fg: blue, Bg: TrueColor(100, 50, 0)}); st != exp {
+		t.Errorf("Expected %+v but result was %+v", exp, st)
+	}
+	st = Style{Fg: blue, Bg: c}.LightenBg(0.5)
+	if exp := (Style{Fg: blue, Bg: TrueColor(228, 178, 128)}); st != exp {
+		t.Errorf("Expected %+v but result was %+v", exp, st)
+	}
+}
+
+var merges = []struct {
+	s, override, exp Style
+}{
+	{Style{Fg: red, Attrs: Bold}, Style{Attrs: Underline}, Style{Fg: red, Attrs: Bold | Underline}},
+	{Style{Fg: red, Bg: blue}, Style{Fg: blue}, Style{Fg: blue, Bg: blue}},
+	{Style{Fg: red, Bg: blue}, Style{Fg: DefaultColor}, Style{Fg: DefaultColor, Bg: blue}},
+	{Style{Fg: DefaultColor}, Style{}, Style{Fg: DefaultColor}},
+	{Style{Attrs: Bold | Dim}, Style{Off: Bold}, Style{Attrs: Dim, Off: Bold}},
+	{Style{Off: Bold}, Style{Attrs: Bold}, Style{Attrs: Bold}},
+	{Style{Off: Bold}, Style{}, Style{Off: Bold}},
+	{Style{Attrs: Reverse}, Style{Attrs: Blink, Off: Blink | Reverse}, Style{Attrs: Blink, Off: Reverse}},
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	for _, tc := range merges {
+		if r := tc.s.Merge(tc.override); r != tc.exp {
+			t.Errorf("Expected %+v from %+v merged with %+v but result was %+v", tc.exp, tc.s, tc.override, r)
+		}
+	}
+}
+
+var styleStrings = map[string]Style{
+	"":                              {},
+	"fgRed":                         {Fg: red},
+	"fg208+bgDefault":               {Fg: PaletteColor(208), Bg: DefaultColor},
+	"fg#0a0b0c+bgBrightWhite":       {Fg: TrueColor(10, 11, 12), Bg: PaletteColor(15)},
+	"bold+underline":                {Attrs: Underline | Bold},
+	"fgDefault+dim+-blink+-reverse": {Fg: DefaultColor, Attrs: Dim, Off: Reverse | Blink | Dim},
+}
+
+func TestStyleString(t *testing.T) {
+	t.Parallel()
+	for k, v := range styleStrings {
+		if r := v.String(); r != k {
+			t.Errorf("Expected %q but result was %q", k, r)
+		}
+		if k == "" || v.Off != 0 {
+			continue
+		}
+		st, err := ParseStyle(k)
+		if err != nil {
+			t.Errorf("Unexpected error from %q: %v", k, err)
+		} else if !st.Equal(v) {
+			t.Errorf("Expected %v from %q but result was %v", v, k, st)
+		}
+	}
+}
+
+func TestStyleEqual(t *testing.T) {
+	t.Parallel()
+	equal := [][2]string{
+		{"fgRed", "fg1"},
+		{"fgRed", "fgansi1"},
+		{"fg196+bold", "bold+fgcube(5,0,0)"},
+		{"bgBrightBlack", "bg8"},
+	}
+	for _, specs := range equal {
+		a, _ := ParseStyle(specs[0])
+		b, _ := ParseStyle(specs[1])
+		if !a.Equal(b) || a.String() != b.String() {
+			t.Errorf("Expected %q to equal %q but the results were %v and %v", specs[0], specs[1], a, b)
+		}
+	}
+	notEqual := [][2]Style{
+		{{Fg: PaletteColor(196)}, {Fg: TrueColor(255, 0, 0)}},
+		{{}, {Fg: DefaultColor}},
+		{{Attrs: Bold}, {Attrs: Bold | Dim}},
+		{{}, {Off: Bold}},
+	}
+	for _, styles := range notEqual {
+		if styles[0].Equal(styles[1]) {
+			t.Errorf("Expected %v not to equal %v", styles[0], styles[1])
+		}
+	}
+	if a, b := (Style{Attrs: Bold, Off: Bold | Dim}), (Style{Attrs: Bold, Off: Dim}); !a.Equal(b) {
+		t.Errorf("Expected %v to equal %v", a, b)
+	}
+}
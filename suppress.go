@@ -0,0 +1,33 @@
+package color
+
+import "sync"
+
+var (
+	suppressedMu sync.RWMutex
+	suppressed   map[string]bool
+)
+
+// SuppressAttributes makes the highlight verbs drop the modes named in attrs, e.g. "blink"
+// and "reverse", while every other attribute is still written. It replaces the modes
+// suppressed by any previous call, calling it without arguments suppresses nothing.
+// The reset mode cannot be suppressed.
+// Formats that were prepared before the call are not affected.
+// It is safe to call SuppressAttributes concurrently.
+func SuppressAttributes(attrs ...string) {
+	m := make(map[string]bool, len(attrs))
+	for _, a := range attrs {
+		m[a] = true
+	}
+	suppressedMu.Lock()
+	suppressed = m
+	suppressedMu.Unlock()
+	// Registered styles are processed once so they must be processed again.
+	rehighlightStyles()
+}
+
+// isSuppressed returns true if the mode a is suppressed.
+func isSuppressed(a string) bool {
+	suppressedMu.RLock()
+	defer suppressedMu.RUnlock()
+	return suppressed[a]
+}
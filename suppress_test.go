@@ -0,0 +1,38 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/nhooyr/terminfo/caps"
+)
+
+// Not parallel because it changes global state.
+func TestSuppressAttributes(t *testing.T) {
+	SuppressAttributes("blink", "reverse", "reset")
+	defer SuppressAttributes()
+	exp := exp(ti.Color(caps.Red, -1)+ti.Strings[caps.EnterBoldMode]) + "hi" + exp(ti.Strings[caps.ExitAttributeMode])
+	if r := Highlight("%h[fgRed+blink+bold+reverse]hi%r"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	SuppressAttributes()
+	exp = expF(ti.Color(caps.Red, -1)+ti.Strings[caps.EnterBlinkMode]+"%s", "hi")
+	if r := Highlight("%h[fgRed+blink]hi"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+}
+
+// Not parallel because it changes global state.
+func TestSuppressAttributesStyles(t *testing.T) {
+	if err := RegisterStyle("suppressBase", "fgRed+blink"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := RegisterStyle("suppressOuter", "suppressBase+bold"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	SuppressAttributes("blink")
+	defer SuppressAttributes()
+	exp := exp(ti.Color(caps.Red, -1) + ti.Strings[caps.EnterBoldMode])
+	if r := Highlight("%h[suppressOuter]"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+}
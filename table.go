@@ -0,0 +1,127 @@
+package color
+
+import (
+	"io"
+	"strings"
+)
+
+// tableStyles holds the styles of a Table.
+var tableStyles = [...]struct {
+	name, spec string
+}{
+	tableHeader: {"tableHeader", "bold+underline"},
+	tableStripe: {"tableStripe", "bg236"},
+}
+
+// The parts of a Table that are styled.
+const (
+	tableHeader = iota
+	tableStripe
+)
+
+// tableGap is the number of spaces between the columns of a Table.
+const tableGap = 2
+
+// Table is a table of cells that is rendered with its columns aligned, e.g. for the
+// output of a command line tool. The cells may contain escape sequences, such as the
+// output of Style.Sprint, as the columns are aligned by the visible width of the cells.
+// The header cells are written in the style registered as tableHeader, or bold and
+// underlined if it is not registered. It is not safe for concurrent use.
+type Table struct {
+	headers []string
+	rows    [][]string
+	zebra   bool
+}
+
+// NewTable returns a new Table with a header row made of headers.
+// There is no header row if there are no headers.
+func NewTable(headers ...string) *Table {
+	return &Table{headers: headers}
+}
+
+// AddRow adds a row made of cells to t. Rows may have fewer cells than other rows,
+// the missing cells are empty.
+func (t *Table) AddRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// SetZebra sets whether every other row after the header row, starting with the second,
+// is striped, written in the style registered as tableStripe, or with the background
+// color 236 of the 256 colors if it is not registered. A striped row is padded to the
+// width of the table so that the stripe covers all of it.
+func (t *Table) SetZebra(on bool) {
+	t.zebra = on
+}
+
+// Render writes t to w, one line per row. The columns are separated by two spaces and
+// every cell is padded with spaces to the width of its column, except for the last cell
+// of a row that is not striped. The cells of a striped row have the stripe set again
+// after every reset in them. The color argument dictates whether color output is
+// enabled, if it is not, the escape sequences in the cells are removed and t is written
+// plain. It returns any write error encountered.
+func (t *Table) Render(w io.Writer, color bool) error {
+	color = color && tiErr == nil
+	var starts [len(tableStyles)]string
+	if color {
+		for i, ts := range tableStyles {
+			starts[i] = themedStart(ts.name, ts.spec)
+		}
+	}
+	rows := t.rows
+	if len(t.headers) > 0 {
+		rows = append([][]string{t.headers}, rows...)
+	}
+	var widths []int
+	for _, row := range rows {
+		for i, c := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := VisibleWidth(c); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	var b strings.Builder
+	for r, row := range rows {
+		header := r == 0 && len(t.headers) > 0
+		data := r
+		if len(t.headers) > 0 {
+			data--
+		}
+		stripe := ""
+		if t.zebra && !header && data%2 == 1 {
+			stripe = starts[tableStripe]
+		}
+		cols := len(row)
+		if stripe != "" {
+			cols = len(widths)
+		}
+		b.WriteString(stripe)
+		for i := 0; i < cols; i++ {
+			var c string
+			if i < len(row) {
+				c = row[i]
+			}
+			if !color {
+				c = stripEscapes(c)
+			} else if header && c != "" {
+				c = starts[tableHeader] + reapply(c, starts[tableHeader]) + reset()
+			} else if stripe != "" {
+				c = reapply(c, stripe)
+			}
+			b.WriteString(c)
+			if i < cols-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-VisibleWidth(c)+tableGap))
+			} else if stripe != "" {
+				b.WriteString(strings.Repeat(" ", widths[i]-VisibleWidth(c)))
+			}
+		}
+		if stripe != "" {
+			b.WriteString(reset())
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
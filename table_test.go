@@ -0,0 +1,46 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+	tb := NewTable("NAME", "STATUS")
+	tb.AddRow("foo", Style{Fg: PaletteColor(2)}.Sprint("ok"))
+	tb.AddRow("世界", "failed", "extra")
+	tb.AddRow("x")
+	var b bytes.Buffer
+	if err := tb.Render(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	exp := "NAME  STATUS\n" +
+		"foo   ok\n" +
+		"世界  failed  extra\n" +
+		"x\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+
+	if tiErr != nil {
+		t.Skip(tiErr)
+	}
+	tb.SetZebra(true)
+	tb.AddRow("y", Style{Fg: PaletteColor(2)}.Sprint("ok"))
+	b.Reset()
+	if err := tb.Render(&b, true); err != nil {
+		t.Fatal(err)
+	}
+	h := Highlight("%h[bold+underline]")
+	s := Highlight("%h[bg236]")
+	r := reset()
+	exp = h + "NAME" + r + "  " + h + "STATUS" + r + "\n" +
+		"foo   \x1b[32mok" + Reset + "\n" +
+		s + "世界  failed  extra" + r + "\n" +
+		"x\n" +
+		s + "y     \x1b[32mok" + Reset + s + "           " + r + "\n"
+	if b.String() != exp {
+		t.Errorf("Expected %q but result was %q", exp, b.String())
+	}
+}
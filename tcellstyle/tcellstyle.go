@@ -0,0 +1,78 @@
+/*
+Package tcellstyle translates the attributes of highlight verbs into the colors and
+attributes of the tcell terminal library, so that the same specs can style a tcell
+screen. It does not import tcell, the values are those of its Color and AttrMask types:
+
+	fg, bg, attrs, err := tcellstyle.FromSpec("fgRed+bold")
+	style := tcell.StyleDefault.Foreground(tcell.Color(fg)).
+		Background(tcell.Color(bg)).Attributes(tcell.AttrMask(attrs))
+*/
+package tcellstyle
+
+import "github.com/nhooyr/color"
+
+// ColorDefault is the tcell color that leaves the terminal default color.
+const ColorDefault int32 = -1
+
+// ColorIsRGB is set in tcell colors that hold a true color as 0xRRGGBB in their low bits.
+const ColorIsRGB int32 = 1 << 24
+
+// The tcell attributes.
+const (
+	AttrBold uint = 1 << iota
+	AttrBlink
+	AttrReverse
+	AttrUnderline
+	AttrDim
+)
+
+// attrMasks maps the mode attributes of a color.Style to the tcell attributes.
+var attrMasks = []struct {
+	a     color.Attr
+	tcell uint
+}{
+	{color.Bold, AttrBold},
+	{color.Blink, AttrBlink},
+	{color.Reverse, AttrReverse},
+	{color.Underline, AttrUnderline},
+	{color.Dim, AttrDim},
+}
+
+// FromSpec returns the tcell foreground and background colors and attributes of the
+// attributes in spec, e.g. "fgRed+bold". It returns an error if spec is invalid.
+// Attributes that a color.Style cannot represent, such as raw, are ignored.
+func FromSpec(spec string) (fg, bg int32, attrs uint, err error) {
+	st, err := color.ParseStyle(spec)
+	if err != nil {
+		return ColorDefault, ColorDefault, 0, err
+	}
+	fg, bg, attrs = FromStyle(st)
+	return fg, bg, attrs, nil
+}
+
+// FromStyle returns the tcell foreground and background colors and attributes of st.
+func FromStyle(st color.Style) (fg, bg int32, attrs uint) {
+	return fromColor(st.Fg), fromColor(st.Bg), fromAttrs(st.Attrs)
+}
+
+// fromColor returns the tcell color of c.
+func fromColor(c color.Color) int32 {
+	if n, ok := c.Index(); ok {
+		return int32(n)
+	}
+	if c, ok := c.RGB(); ok {
+		return ColorIsRGB | int32(c.R)<<16 | int32(c.G)<<8 | int32(c.B)
+	}
+	return ColorDefault
+}
+
+// fromAttrs returns the tcell attributes of a.
+func fromAttrs(a color.Attr) uint {
+	var m uint
+	for _, at := range attrMasks {
+		if a&at.a != 0 {
+			m |= at.tcell
+		}
+	}
+	return m
+}
@@ -0,0 +1,33 @@
+package tcellstyle
+
+import "testing"
+
+var specs = []struct {
+	spec   string
+	fg, bg int32
+	attrs  uint
+}{
+	{"fgRed", 1, ColorDefault, 0},
+	{"bgBrightBlue+bold", ColorDefault, 12, AttrBold},
+	{"fg208+bg#102030", 208, 0x1102030, 0},
+	{"underline+reverse+dim+blink", ColorDefault, ColorDefault, AttrUnderline | AttrReverse | AttrDim | AttrBlink},
+	{"fg#ffffff+bgDefault", 0x1ffffff, ColorDefault, 0},
+	{"bold+reset+fgBlue", 4, ColorDefault, 0},
+}
+
+func TestFromSpec(t *testing.T) {
+	t.Parallel()
+	for _, tc := range specs {
+		fg, bg, attrs, err := FromSpec(tc.spec)
+		if err != nil {
+			t.Errorf("Unexpected error from %q: %v", tc.spec, err)
+			continue
+		}
+		if fg != tc.fg || bg != tc.bg || attrs != tc.attrs {
+			t.Errorf("Expected %#x, %#x, %#x from %q but result was %#x, %#x, %#x", tc.fg, tc.bg, tc.attrs, tc.spec, fg, bg, attrs)
+		}
+	}
+	if _, _, _, err := FromSpec("fgRedd"); err == nil {
+		t.Errorf("Expected an error from %q", "fgRedd")
+	}
+}
@@ -0,0 +1,92 @@
+package color
+
+import (
+	"io"
+	"strings"
+)
+
+// NewTeeWriter returns an io.Writer that writes everything to both tty and plain, but
+// removes escape sequences from what it writes to plain. It is meant for printing colored
+// output to a terminal while keeping a clean copy in a file.
+// An escape sequence split across writes is held back from plain until it is complete,
+// so plain never receives a fragment of one. An incomplete sequence longer than 256
+// bytes, e.g. an unterminated OSC, is dropped instead so that the memory held back is
+// bounded, and what follows it is written as text.
+// The returned writer is not safe for concurrent use.
+func NewTeeWriter(tty io.Writer, plain io.Writer) io.Writer {
+	return &teeWriter{tty: tty, plain: plain}
+}
+
+// teeWriter writes to a terminal and writes the same output stripped of
+// escape sequences to another writer.
+type teeWriter struct {
+	tty   io.Writer
+	plain io.Writer
+	buf   []byte // incomplete escape sequence held back from plain
+	out   []byte // reused for the stripped output
+}
+
+func (tw *teeWriter) Write(p []byte) (n int, err error) {
+	n, err = tw.tty.Write(p)
+	if err != nil {
+		return n, err
+	}
+	s := string(append(tw.buf, p...))
+	tw.buf = tw.buf[:0]
+	out := tw.out[:0]
+	for s != "" {
+		i := strings.IndexByte(s, '\x1b')
+		if i == -1 {
+			out = append(out, s...)
+			break
+		}
+		out = append(out, s[:i]...)
+		s = s[i:]
+		if l := escapeLen(s); l > 0 {
+			s = s[l:]
+		} else if escapePrefix(s) {
+			if len(s) <= maxEscapePrefix {
+				tw.buf = append(tw.buf, s...)
+			}
+			break
+		} else {
+			// Drop the ESC that does not start an escape sequence.
+			s = s[1:]
+		}
+	}
+	tw.out = out
+	if len(out) > 0 {
+		if _, err = tw.plain.Write(out); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// maxEscapePrefix is the length of the longest incomplete escape sequence that is held
+// back until the rest of it is written.
+const maxEscapePrefix = 256
+
+// escapePrefix returns true if s is the start of an escape sequence that is not yet
+// complete. The sequence may still turn out to be invalid with more bytes.
+func escapePrefix(s string) bool {
+	if len(s) < 2 {
+		return s == "\x1b"
+	}
+	i := 2
+	switch s[1] {
+	case '[':
+		for i < len(s) && s[i] >= 0x30 && s[i] <= 0x3f {
+			i++
+		}
+	case ']', 'P', 'X', '^', '_':
+		// Terminated by BEL or ST, neither of which was found.
+		return escapeLen(s) == 0
+	default:
+		i = 1
+	}
+	for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+		i++
+	}
+	return i == len(s)
+}
@@ -0,0 +1,44 @@
+package color
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var teeWrites = []struct {
+	writes []string
+	plain  string
+}{
+	{[]string{"foo"}, "foo"},
+	{[]string{"\x1b[31mfoo\x1b[0m bar"}, "foo bar"},
+	{[]string{"\x1b[3", "1mfoo\x1b", "[0m"}, "foo"},
+	{[]string{"foo\x1b", "]0;title\x1b", "\\bar"}, "foobar"},
+	{[]string{"foo\x1b[3", "1", "m", "bar"}, "foobar"},
+	{[]string{"\x1b\tfoo\x1b\x1b[1m"}, "\tfoo"},
+	{[]string{"foo\x1b[3"}, "foo"},
+	{[]string{"\x1b(", "Bfoo"}, "foo"},
+	{[]string{"\x1b[1\tm"}, "[1\tm"},
+	{[]string{"foo\x1b]0;", strings.Repeat("a", 200), strings.Repeat("a", 200), "b"}, "foob"},
+}
+
+func TestTeeWriter(t *testing.T) {
+	t.Parallel()
+	for _, tc := range teeWrites {
+		var tty, plain bytes.Buffer
+		w := NewTeeWriter(&tty, &plain)
+		var all string
+		for _, s := range tc.writes {
+			all += s
+			if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+				t.Errorf("Unexpected write result %d, %v from %q", n, err, s)
+			}
+		}
+		if tty.String() != all {
+			t.Errorf("Expected %q but result was %q", all, tty.String())
+		}
+		if plain.String() != tc.plain {
+			t.Errorf("Expected %q from %q but result was %q", tc.plain, tc.writes, plain.String())
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package color
+
+import (
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+func isTerminal(f *os.File) bool {
+	return terminal.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package color
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var procGetFileInformationByHandleEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetFileInformationByHandleEx")
+
+// fileNameInfo is the FILE_INFO_BY_HANDLE_CLASS for FILE_NAME_INFO.
+const fileNameInfo = 2
+
+func isTerminal(f *os.File) bool {
+	return terminal.IsTerminal(int(f.Fd())) || isCygwinPty(syscall.Handle(f.Fd()))
+}
+
+// isCygwinPty returns true if h is one of the named pipes that MSYS2 and Cygwin
+// use as pseudo terminals. Those render ANSI sequences just fine.
+// Any error during the detection means h is not considered a terminal.
+func isCygwinPty(h syscall.Handle) bool {
+	if procGetFileInformationByHandleEx.Find() != nil {
+		return false
+	}
+	if t, err := syscall.GetFileType(h); err != nil || t != syscall.FILE_TYPE_PIPE {
+		return false
+	}
+	// FILE_NAME_INFO is a DWORD holding the length of the name in bytes,
+	// followed by the name in UTF-16.
+	var buf [2 + syscall.MAX_PATH]uint16
+	r, _, _ := procGetFileInformationByHandleEx.Call(uintptr(h), fileNameInfo, uintptr(unsafe.Pointer(&buf)), uintptr(len(buf)*2))
+	if r == 0 {
+		return false
+	}
+	n := (uint32(buf[0]) | uint32(buf[1])<<16) / 2
+	if n > syscall.MAX_PATH {
+		return false
+	}
+	return isCygwinPtyName(string(utf16.Decode(buf[2 : 2+n])))
+}
+
+// isCygwinPtyName returns true if name matches the pipe names of MSYS2 and Cygwin
+// pseudo terminals, e.g. `\msys-dd50a72ab4668b33-pty1-to-master`.
+func isCygwinPtyName(name string) bool {
+	if !strings.HasPrefix(name, `\msys-`) && !strings.HasPrefix(name, `\cygwin-`) {
+		return false
+	}
+	i := strings.Index(name, "-pty")
+	if i == -1 {
+		return false
+	}
+	name = name[i+len("-pty"):]
+	i = strings.IndexByte(name, '-')
+	if i < 1 || strings.Trim(name[:i], "0123456789") != "" {
+		return false
+	}
+	name = name[i:]
+	return name == "-from-master" || name == "-to-master"
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package color
+
+import "testing"
+
+var cygwinPtyNames = map[string]bool{
+	`\msys-dd50a72ab4668b33-pty1-to-master`:     true,
+	`\msys-dd50a72ab4668b33-pty12-from-master`:  true,
+	`\cygwin-e022582115c10879-pty0-from-master`: true,
+	`\cygwin-e022582115c10879-pty0-to-master`:   true,
+	`\msys-dd50a72ab4668b33-pty-to-master`:      false,
+	`\msys-dd50a72ab4668b33-ptyx-to-master`:     false,
+	`\msys-dd50a72ab4668b33-pty1-to-slave`:      false,
+	`\msys-dd50a72ab4668b33`:                    false,
+	`\Device\NamedPipe\foo`:                     false,
+	``:                                          false,
+}
+
+func TestIsCygwinPtyName(t *testing.T) {
+	t.Parallel()
+	for k, v := range cygwinPtyNames {
+		if r := isCygwinPtyName(k); r != v {
+			t.Errorf("Expected %v from %q but result was %v", v, k, r)
+		}
+	}
+}
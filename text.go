@@ -0,0 +1,80 @@
+package color
+
+import (
+	"bytes"
+	"strings"
+)
+
+// EqualText returns true if a and b are the same text once their escape sequences are
+// removed, so that only the text and not its style is compared. It is meant for tests
+// of colored output.
+func EqualText(a, b string) bool {
+	return stripEscapes(a) == stripEscapes(b)
+}
+
+// TextDiff returns a line by line diff of the text of a and b without their escape
+// sequences, or an empty string if EqualText(a, b). Lines only in a are prefixed with
+// "-", lines only in b with "+" and lines in both with " ". Every line of the diff
+// ends with a newline.
+func TextDiff(a, b string) string {
+	a, b = stripEscapes(a), stripEscapes(b)
+	if a == b {
+		return ""
+	}
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var buf bytes.Buffer
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			buf.WriteString(" " + al[i] + "\n")
+			i++
+			j++
+		case j == len(bl) || i < len(al) && lcs[i+1][j] >= lcs[i][j+1]:
+			buf.WriteString("-" + al[i] + "\n")
+			i++
+		default:
+			buf.WriteString("+" + bl[j] + "\n")
+			j++
+		}
+	}
+	return buf.String()
+}
+
+// stripEscapes returns s without its escape sequences.
+// Unlike Sanitize, it keeps control characters.
+func stripEscapes(s string) string {
+	i := strings.IndexByte(s, '\x1b')
+	if i == -1 {
+		return s
+	}
+	var buf bytes.Buffer
+	for i != -1 {
+		buf.WriteString(s[:i])
+		s = s[i:]
+		n := escapeLen(s)
+		if n == 0 {
+			n = 1
+		}
+		s = s[n:]
+		i = strings.IndexByte(s, '\x1b')
+	}
+	buf.WriteString(s)
+	return buf.String()
+}
@@ -0,0 +1,35 @@
+package color
+
+import "testing"
+
+func TestEqualText(t *testing.T) {
+	t.Parallel()
+	equal := []struct {
+		a, b string
+		exp  bool
+	}{
+		{"\x1b[31mfoo\x1b[0m bar", "foo \x1b[1mbar", true},
+		{"\x1b]8;;http://x\x1b\\foo\x1b]8;;\x1b\\", "foo", true},
+		{"foo\tbar\n", "\x1b[Kfoo\tbar\n", true},
+		{"\x1b[31mfoo", "\x1b[31mfoo ", false},
+		{"foo", "\x1b[31mf\x1b[0mo", false},
+	}
+	for _, tc := range equal {
+		if r := EqualText(tc.a, tc.b); r != tc.exp {
+			t.Errorf("Expected %v from %q and %q but result was %v", tc.exp, tc.a, tc.b, r)
+		}
+	}
+}
+
+func TestTextDiff(t *testing.T) {
+	t.Parallel()
+	if r := TextDiff("\x1b[31mfoo\nbar", "foo\n\x1b[1mbar"); r != "" {
+		t.Errorf("Expected %q but result was %q", "", r)
+	}
+	a := "\x1b[31mfoo\x1b[0m\nbar\nbaz"
+	b := "foo\nqux\n\x1b[1mbaz\nend"
+	exp := " foo\n-bar\n+qux\n baz\n+end\n"
+	if r := TextDiff(a, b); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+}
@@ -0,0 +1,288 @@
+package color
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// style is a registered style.
+type style struct {
+	spec    string // attributes as they appear in the highlight verb
+	colored string // processed spec
+}
+
+var (
+	stylesMu sync.RWMutex
+	styles   = make(map[string]style)
+)
+
+// RegisterStyle registers spec under name so that name can be used as an attribute in
+// the highlight verb. The spec argument is the list of attributes as it would appear
+// between the brackets of the highlight verb, e.g. "fgRed+bold" makes %h[name] equivalent
+// to %h[fgRed+bold]. Registering a name again replaces its spec.
+// The name must consist of only letters and digits, it must not start with "fg" or "bg"
+// and it must not be a mode.
+// It is safe to call RegisterStyle concurrently.
+func RegisterStyle(name, spec string) error {
+	if err := checkStyleName(name); err != nil {
+		return err
+	}
+	if err := checkSpec(spec); err != nil {
+		return err
+	}
+	st := style{spec, Highlight(verb(spec))}
+	stylesMu.Lock()
+	defer stylesMu.Unlock()
+	styles[name] = st
+	return nil
+}
+
+// RegisterAttribute registers name as an attribute that writes a SGR sequence with codes
+// as its parameters, e.g. RegisterAttribute("warn", []int{33, 1}) makes %h[warn]
+// equivalent to %h[fgYellow+bold]. It is a style with the spec "raw=" followed by the
+// codes separated by semicolons, so the naming rules of RegisterStyle apply and it is
+// listed by Styles. The codes must be from 0-107 except for extended colors, which are
+// 38, 48 or 58 followed by 5;n or 2;r;g;b.
+// The codes are written as given: a reset clears them like any other attribute and
+// Minify merges them with the SGR sequences around them.
+func RegisterAttribute(name string, codes []int) error {
+	if err := checkCodes(codes); err != nil {
+		return err
+	}
+	params := make([]string, len(codes))
+	for i, c := range codes {
+		params[i] = strconv.Itoa(c)
+	}
+	return RegisterStyle(name, "raw="+strings.Join(params, ";"))
+}
+
+// LoadThemeFromEnv registers the styles in the environment variable named varName.
+// The variable holds a list of name=spec entries separated by semicolons, e.g.
+// "error=fgRed+bold;warn=fgYellow". An entry may name the styles of earlier entries in
+// its spec, e.g. "base=fgBlue;title=base+bold". Nothing is registered if any entry is
+// malformed or has an invalid name or spec, and the error names the bad attribute as
+// ValidateTheme does. An unset or empty variable is not an error.
+func LoadThemeFromEnv(varName string) error {
+	v := os.Getenv(varName)
+	if v == "" {
+		return nil
+	}
+	entries := strings.Split(v, ";")
+	names := make([]string, len(entries))
+	specs := make([]string, len(entries))
+	earlier := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		j := strings.IndexByte(e, '=')
+		if j == -1 {
+			return fmt.Errorf("color: missing '=' in entry %q of $%s", e, varName)
+		}
+		names[i], specs[i] = e[:j], e[j+1:]
+		if err := checkStyleName(names[i]); err != nil {
+			return fmt.Errorf("%v in $%s", err, varName)
+		}
+		if bad := badAttributes(names[i], specs[i], earlier); len(bad) > 0 {
+			return fmt.Errorf("color: bad spec %q of style %q in $%s: bad attribute %s", specs[i], names[i], varName, strings.Join(bad, ", "))
+		}
+		earlier[names[i]] = true
+	}
+	for i := range names {
+		// Already checked.
+		RegisterStyle(names[i], specs[i])
+	}
+	return nil
+}
+
+// ValidateTheme checks the styles in specs, which maps their names to their specs as they
+// would be given to RegisterStyle, and returns an error for each invalid entry in the
+// order of the names. An error names the style and each bad attribute in its spec, e.g.
+// fgRedd in "fgRedd+bold", along with the problem as it appears in the output of the
+// highlight verb, rather than stopping at the first one, so that all the mistakes in a
+// theme can be reported at once. An invalid name is reported by itself. Attributes
+// naming other styles in specs are valid even though they are not registered yet.
+// Nothing is registered. It returns nil if the theme is valid.
+func ValidateTheme(specs map[string]string) []error {
+	names := make([]string, 0, len(specs))
+	known := make(map[string]bool, len(specs))
+	for name := range specs {
+		names = append(names, name)
+		known[name] = true
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		if err := checkStyleName(name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if bad := badAttributes(name, specs[name], known); len(bad) > 0 {
+			errs = append(errs, fmt.Errorf("color: bad spec %q of style %q: bad attribute %s", specs[name], name, strings.Join(bad, ", ")))
+		}
+	}
+	return errs
+}
+
+// badAttributes returns each bad attribute in spec, the spec of the style name, quoted
+// and followed by the problem, e.g. `"fgRedd" %!h(BADATTR)`. Attributes naming the other
+// styles in known are valid even though they are not registered, so spec is not bad if
+// it only fails because of them.
+func badAttributes(name, spec string, known map[string]bool) []string {
+	if specError(spec) == "" {
+		return nil
+	}
+	var bad []string
+	for _, a := range strings.Split(spec, "+") {
+		if known[a] && a != name {
+			continue
+		}
+		if e := specError(a); e != "" {
+			bad = append(bad, fmt.Sprintf("%q %s", a, e))
+		}
+	}
+	return bad
+}
+
+// Styles returns a copy of the registered styles, mapping their names to their specs.
+func Styles() map[string]string {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	m := make(map[string]string, len(styles))
+	for name, st := range styles {
+		m[name] = st.spec
+	}
+	return m
+}
+
+// LookupStyle returns the spec that is registered under name.
+// The ok result is false if there is no such style.
+func LookupStyle(name string) (spec string, ok bool) {
+	st, ok := lookupStyle(name)
+	return st.spec, ok
+}
+
+// rehighlightStyles processes the specs of all registered styles again. The specs are
+// processed without holding stylesMu, as processing a style that refers to another style
+// looks it up. Such a style is processed with the previous output of the other one, so
+// the specs are processed again until nothing changes, at most once per level of
+// nesting that trackStyle follows.
+func rehighlightStyles() {
+	for i := 0; i <= maxStyleDepth; i++ {
+		stylesMu.RLock()
+		specs := make(map[string]string, len(styles))
+		for name, st := range styles {
+			specs[name] = st.spec
+		}
+		stylesMu.RUnlock()
+		colored := make(map[string]string, len(specs))
+		for name, spec := range specs {
+			colored[name] = Highlight(verb(spec))
+		}
+		changed := false
+		stylesMu.Lock()
+		for name, c := range colored {
+			// Skip styles that were registered again in the meantime.
+			if st, ok := styles[name]; ok && st.spec == specs[name] && st.colored != c {
+				st.colored = c
+				styles[name] = st
+				changed = true
+			}
+		}
+		stylesMu.Unlock()
+		if !changed {
+			return
+		}
+	}
+}
+
+func lookupStyle(name string) (style, bool) {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	st, ok := styles[name]
+	return st, ok
+}
+
+// themedStart returns the processed style registered as name, or the processed spec
+// if there is none, for the output that can be themed, e.g. the tokens of JSON.
+func themedStart(name, spec string) string {
+	if st, ok := lookupStyle(name); ok {
+		return st.colored
+	}
+	return Highlight(verb(spec))
+}
+
+// checkStyleName returns an error if name cannot be used for a style.
+func checkStyleName(name string) error {
+	if name == "" {
+		return errors.New("color: empty style name")
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') {
+			return fmt.Errorf("color: invalid character %q in style name %q", ch, name)
+		}
+	}
+	if len(name) >= 2 && (name[:2] == "fg" || name[:2] == "bg") {
+		return fmt.Errorf("color: style name %q starts with %q", name, name[:2])
+	}
+	if strings.HasPrefix(name, "ulcolor") {
+		return fmt.Errorf("color: style name %q starts with %q", name, "ulcolor")
+	}
+	if _, ok := modes[name]; ok {
+		return fmt.Errorf("color: style name %q is a mode", name)
+	}
+	if _, ok := controls[name]; ok || name == "linkend" {
+		return fmt.Errorf("color: style name %q is a control", name)
+	}
+	return nil
+}
+
+// checkSpec returns an error if spec is not a valid list of attributes.
+func checkSpec(spec string) error {
+	if e := specError(spec); e != "" {
+		return fmt.Errorf("color: bad spec %q: %s", spec, e)
+	}
+	return nil
+}
+
+// specError returns a description of the problem with spec as a list of attributes,
+// e.g. "%!h(BADATTR)", or an empty string if spec is valid.
+func specError(spec string) string {
+	hl := newHighlighter(verb(spec), false)
+	defer hl.free()
+	s := hl.run()
+	if hl.err != "" {
+		// Drop the extra '%' meant for fmt.
+		return hl.err[1:]
+	}
+	if s != "" {
+		return fmt.Sprintf("trailing %q", s)
+	}
+	return ""
+}
+
+// swatchWidth is the number of columns a swatch is padded to.
+const swatchWidth = 24
+
+// Swatch returns label in the style of spec, padded with spaces in the same style to
+// 24 columns so that a list of swatches aligns, e.g. to preview the registered styles.
+// The spec argument is the list of attributes as it would appear between the brackets
+// of the highlight verb. If spec is invalid, the padded label is returned plain and
+// followed by the error, e.g. "%!h(BADATTR)". The color argument dictates whether
+// color output is enabled.
+func Swatch(spec, label string, color bool) string {
+	if w := VisibleWidth(label); w < swatchWidth {
+		label += strings.Repeat(" ", swatchWidth-w)
+	}
+	if e := specError(spec); e != "" {
+		return label + " " + e
+	}
+	start := Run(verb(spec), color)
+	if start == "" {
+		return label
+	}
+	return start + label + reset()
+}
@@ -0,0 +1,197 @@
+package color
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nhooyr/terminfo/caps"
+)
+
+func TestRegisterStyle(t *testing.T) {
+	t.Parallel()
+	if err := RegisterStyle("danger", "fgRed+bold"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := exp(ti.Color(caps.Red, -1)+ti.Strings[caps.EnterBoldMode]+ti.Color(-1, caps.Blue)) + "hi"
+	if r := Highlight("%h[danger+bgBlue]hi"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r := Strip("%h[danger]hi"); r != "hi" {
+		t.Errorf("Expected %q but result was %q", "hi", r)
+	}
+	spec, ok := LookupStyle("danger")
+	if !ok || spec != "fgRed+bold" {
+		t.Errorf("Expected %q but result was %q", "fgRed+bold", spec)
+	}
+	if _, ok := LookupStyle("nonexistent"); ok {
+		t.Errorf("Expected no style named %q", "nonexistent")
+	}
+	m := Styles()
+	if m["danger"] != "fgRed+bold" {
+		t.Errorf("Expected %q but result was %q", "fgRed+bold", m["danger"])
+	}
+	m["danger"] = "fgGreen"
+	if spec, _ := LookupStyle("danger"); spec != "fgRed+bold" {
+		t.Errorf("Expected %q but result was %q", "fgRed+bold", spec)
+	}
+}
+
+var badStyles = map[string]string{
+	"":       "fgRed",
+	"fgWarn": "fgRed",
+	"bgWarn": "fgRed",
+	"bold":   "fgRed",
+	"a+b":    "fgRed",
+	"warn1":  "fgRedd",
+	"warn2":  "fgRed]hi",
+	"warn3":  "",
+	"warn4":  "fgRed+",
+}
+
+func TestRegisterStyleErrors(t *testing.T) {
+	t.Parallel()
+	for name, spec := range badStyles {
+		if err := RegisterStyle(name, spec); err == nil {
+			t.Errorf("Expected error registering %q as %q", name, spec)
+		}
+	}
+}
+
+func TestLoadThemeFromEnv(t *testing.T) {
+	t.Parallel()
+	os.Setenv("COLOR_TEST_THEME", "envError=fgRed+bold;envWarn=fgYellow")
+	if err := LoadThemeFromEnv("COLOR_TEST_THEME"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spec, _ := LookupStyle("envError"); spec != "fgRed+bold" {
+		t.Errorf("Expected %q but result was %q", "fgRed+bold", spec)
+	}
+	if spec, _ := LookupStyle("envWarn"); spec != "fgYellow" {
+		t.Errorf("Expected %q but result was %q", "fgYellow", spec)
+	}
+	if err := LoadThemeFromEnv("COLOR_TEST_THEME_UNSET"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	os.Setenv("COLOR_TEST_THEME_REF", "envBase=fgBlue;envTitle=envBase+bold")
+	if err := LoadThemeFromEnv("COLOR_TEST_THEME_REF"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := Highlight("%h[fgBlue]%h[bold]") + "hi"
+	if r := Highlight("%h[envTitle]hi"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	os.Setenv("COLOR_TEST_THEME_BAD", "envBad10=envBad11+bold;envBad11=fgRedd")
+	err := LoadThemeFromEnv("COLOR_TEST_THEME_BAD")
+	if err == nil || !strings.Contains(err.Error(), `"envBad11" %!h(BADATTR)`) {
+		t.Errorf("Expected an error naming %q but result was %v", "envBad11", err)
+	}
+	for _, v := range []string{
+		"envBad1=fgRed;",
+		"envBad2=fgRed;envBad3",
+		"envBad4=fgRedd",
+		"envBad5=fgRed;=bold",
+		"envBad6=fgRed;env Bad7=bold",
+		"envBad8=fgRed,envBad9=bold",
+	} {
+		os.Setenv("COLOR_TEST_THEME_BAD", v)
+		if err := LoadThemeFromEnv("COLOR_TEST_THEME_BAD"); err == nil {
+			t.Errorf("Expected error from %q", v)
+		}
+	}
+	if _, ok := LookupStyle("envBad1"); ok {
+		t.Errorf("Expected no style named %q", "envBad1")
+	}
+}
+
+func TestRegisterAttribute(t *testing.T) {
+	t.Parallel()
+	if err := RegisterAttribute("attrWarn", []int{33, 1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := exp("\x1b[33;1m"+ti.Color(-1, caps.Blue)) + "hi"
+	if r := Highlight("%h[attrWarn+bgBlue]hi"); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if err := RegisterAttribute("attrOrange", []int{38, 5, 208, 58, 2, 1, 2, 3}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for name, codes := range map[string][]int{
+		"attrEmpty":  nil,
+		"attrBig":    {108},
+		"attrNeg":    {-1},
+		"attrShort":  {38, 5},
+		"attrIndex":  {48, 5, 256},
+		"bold":       {1},
+		"fgWarn":     {33},
+		"ulcolorRed": {31},
+	} {
+		if err := RegisterAttribute(name, codes); err == nil {
+			t.Errorf("Expected error registering %q as %v", name, codes)
+		}
+	}
+}
+
+func TestSwatch(t *testing.T) {
+	t.Parallel()
+	pad := strings.Repeat(" ", swatchWidth-len("danger"))
+	exp := Highlight("%h[fgRed+bold]") + "danger" + pad + reset()
+	if tiErr != nil {
+		exp = "danger" + pad
+	}
+	if r := Swatch("fgRed+bold", "danger", true); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	if r := Swatch("fgRed+bold", "danger", false); r != "danger"+pad {
+		t.Errorf("Expected %q but result was %q", "danger"+pad, r)
+	}
+	exp = "danger" + pad + " %!h(BADATTR)"
+	if r := Swatch("fgRedd", "danger", true); r != exp {
+		t.Errorf("Expected %q but result was %q", exp, r)
+	}
+	long := strings.Repeat("x", swatchWidth+1)
+	if r := Swatch("bold", long, false); r != long {
+		t.Errorf("Expected %q but result was %q", long, r)
+	}
+}
+
+func TestValidateTheme(t *testing.T) {
+	t.Parallel()
+	valid := map[string]string{
+		"vtError": "fgRed+bold",
+		"vtHex":   "fg#ff00aa+bg#000000",
+		"vtCube":  "fgcube(5,0,0)+bggray23+fg255",
+		"vtRef":   "vtError+underline",
+	}
+	if errs := ValidateTheme(valid); len(errs) != 0 {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+	errs := ValidateTheme(map[string]string{
+		"vtA":      "fgRedd+bold+blod",
+		"vtB":      "fg256+bg#ff00a",
+		"vtC":      "fgcube(6,0,0)+fggray24",
+		"vtD":      "fgRed",
+		"vt E":     "fgRed",
+		"vtF":      "",
+		"vtSelf":   "vtSelf",
+		"vtRefBad": "vtD+dimm",
+	})
+	exp := []string{
+		`color: bad spec "fgRedd+bold+blod" of style "vtA": bad attribute "fgRedd" %!h(BADATTR), "blod" %!h(BADATTR)`,
+		`color: bad spec "fg256+bg#ff00a" of style "vtB": bad attribute "fg256" %!h(BADATTR), "bg#ff00a" %!h(BADATTR)`,
+		`color: bad spec "fgcube(6,0,0)+fggray24" of style "vtC": bad attribute "fgcube(6,0,0)" %!h(BADATTR), "fggray24" %!h(BADATTR)`,
+		`color: bad spec "" of style "vtF": bad attribute "" %!h(MISSING)`,
+		`color: bad spec "vtD+dimm" of style "vtRefBad": bad attribute "dimm" %!h(BADATTR)`,
+		`color: bad spec "vtSelf" of style "vtSelf": bad attribute "vtSelf" %!h(BADATTR)`,
+	}
+	if len(errs) != len(exp)+1 {
+		t.Fatalf("Expected %d errors but result was %v", len(exp)+1, errs)
+	}
+	// The invalid name sorts first.
+	errs = errs[1:]
+	for i, err := range errs {
+		if err.Error() != exp[i] {
+			t.Errorf("Expected %q but result was %q", exp[i], err.Error())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package color
+
+import "unicode/utf8"
+
+// TruncateLeft returns s truncated from the left so that it occupies at most width columns
+// on a terminal, keeping the end of s, e.g. the last directories of a path. If s is wider
+// than width, its start is dropped and replaced with ell, e.g. "…", so that the result
+// including ell is at most width columns, otherwise s is returned unchanged. The SGR
+// sequences in the dropped start that are still active where the kept end starts are
+// written again after ell, so the end looks the same as in s while ell is plain. Other
+// escape sequences in the dropped start are dropped with it. Runes, escape sequences and
+// emoji sequences are never split, so the result may be narrower than width. If width is
+// less than the width of ell, the result is only as much of the end of s as fits.
+func TruncateLeft(s string, width int, ell string) string {
+	if VisibleWidth(s) <= width {
+		return s
+	}
+	avail := width - VisibleWidth(ell)
+	if avail < 0 {
+		avail, ell = width, ""
+	}
+	if avail < 0 {
+		avail = 0
+	}
+	// The start of each rune and escape sequence with the width of the rune. Emoji
+	// sequences are measured forward so only their first rune has a width.
+	type unit struct {
+		start, width int
+		escape       bool
+	}
+	var (
+		pieces []unit
+		cw     clusterWidth
+	)
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if n := escapeLen(s[i:]); n > 0 {
+				pieces = append(pieces, unit{i, 0, true})
+				i += n
+				continue
+			}
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		pieces = append(pieces, unit{i, cw.width(r), false})
+		i += n
+	}
+	// Find the first piece to keep, walking back from the end.
+	cut := len(pieces)
+	w := 0
+	for k := len(pieces) - 1; k >= 0; k-- {
+		if p := pieces[k]; !p.escape && p.width > 0 {
+			if w+p.width > avail {
+				break
+			}
+			w += p.width
+			cut = k
+		}
+	}
+	start := len(s)
+	if cut < len(pieces) {
+		start = pieces[cut].start
+	}
+	var active sgrState
+	for i := 0; i < start; {
+		if n := escapeLen(s[i:]); n > 0 {
+			active.track(s[i : i+n])
+			i += n
+			continue
+		}
+		i++
+	}
+	return ell + active.sgr() + s[start:]
+}
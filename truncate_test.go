@@ -0,0 +1,34 @@
+package color
+
+import "testing"
+
+var leftTruncations = []struct {
+	s     string
+	width int
+	ell   string
+	exp   string
+}{
+	{"/home/foo/bar", 20, "…", "/home/foo/bar"},
+	{"/home/foo/bar", 8, "…", "…foo/bar"},
+	{"/home/foo/bar", 8, "", "/foo/bar"},
+	{"\x1b[31m/home/\x1b[1mfoo\x1b[0m/bar", 8, "…", "…\x1b[1;31mfoo\x1b[0m/bar"},
+	{"\x1b[31m/home\x1b[0m/foo", 5, "…", "…/foo"},
+	{"\x1b[31m/ho\x1b[32mme\x1b[0m/foo", 8, "...", "...\x1b[32me\x1b[0m/foo"},
+	{"世界世界", 5, "…", "…世界"},
+	{"世界世界", 4, "…", "…界"},
+	{"aéé", 2, "…", "…é"},
+	{"a\U0001f468\u200d\U0001f469b", 3, "…", "…b"},
+	{"aa\U0001f468\u200d\U0001f469b", 4, "…", "…\U0001f468\u200d\U0001f469b"},
+	{"\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\", 3, "", "ink\x1b]8;;\x1b\\"},
+	{"foobar", 2, "…..", "ar"},
+	{"foobar", 0, "…", ""},
+}
+
+func TestTruncateLeft(t *testing.T) {
+	t.Parallel()
+	for _, tc := range leftTruncations {
+		if r := TruncateLeft(tc.s, tc.width, tc.ell); r != tc.exp {
+			t.Errorf("Expected %q from %q at %d but result was %q", tc.exp, tc.s, tc.width, r)
+		}
+	}
+}
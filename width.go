@@ -0,0 +1,155 @@
+package color
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// wide holds the runes that occupy two columns on a terminal,
+// the East Asian Wide and Fullwidth characters along with most emoji.
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f0, 1},
+		{0x23f3, 0x23f3, 1},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x267f, 1},
+		{0x2693, 0x2693, 1},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26ce, 1},
+		{0x26d4, 0x26d4, 1},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26f5, 1},
+		{0x26fa, 0x26fa, 1},
+		{0x26fd, 0x26fd, 1},
+		{0x2705, 0x2705, 1},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x2728, 1},
+		{0x274c, 0x274c, 1},
+		{0x274e, 0x274e, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27b0, 1},
+		{0x27bf, 0x27bf, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b50, 1},
+		{0x2b55, 0x2b55, 1},
+		{0x2e80, 0x303e, 1},
+		{0x3041, 0x33ff, 1},
+		{0x3400, 0x4dbf, 1},
+		{0x4e00, 0x9fff, 1},
+		{0xa000, 0xa4cf, 1},
+		{0xa960, 0xa97f, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe6f, 1},
+		{0xff00, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x17000, 0x18aff, 1},
+		{0x1b000, 0x1b2ff, 1},
+		{0x1f004, 0x1f004, 1},
+		{0x1f0cf, 0x1f0cf, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f200, 0x1f251, 1},
+		{0x1f300, 0x1f64f, 1},
+		{0x1f680, 0x1f6ff, 1},
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f90c, 0x1f9ff, 1},
+		{0x1fa70, 0x1faff, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// runeWidth returns the number of columns r occupies on a terminal.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r <= 0x9f):
+		return 0
+	case r < 0x7f:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wide, r):
+		return 2
+	}
+	return 1
+}
+
+// VisibleWidth returns the number of columns s occupies on a terminal. Escape sequences
+// and control characters occupy no columns, nor do combining marks and other zero width
+// characters. East Asian Wide and Fullwidth characters and most emoji occupy two columns
+// and everything else one, as with wcwidth, but emoji sequences are measured as they are
+// rendered: emoji joined by zero width joiners, such as the family emoji, and emoji with
+// skin tone modifiers occupy two columns altogether and a pair of regional indicators,
+// a flag, occupies two. It is the measurement used by PrintfWidth, WrapText and the
+// rest of the package.
+func VisibleWidth(s string) int {
+	var cw clusterWidth
+	w := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if n := escapeLen(s[i:]); n > 0 {
+				i += n
+				continue
+			}
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		w += cw.width(r)
+		i += n
+	}
+	return w
+}
+
+// zwj is the zero width joiner that joins emoji into a single one.
+const zwj = '\u200d'
+
+// clusterWidth measures runes in sequence so that the runes of an emoji sequence
+// are measured as a single emoji.
+type clusterWidth struct {
+	emoji  bool // the previous visible rune was wide and can be joined or modified
+	joined bool // a zero width joiner followed the previous visible rune
+}
+
+// width returns the number of columns r occupies after the runes before it.
+func (cw *clusterWidth) width(r rune) int {
+	if r == zwj {
+		cw.joined = cw.emoji
+		return 0
+	}
+	rw := runeWidth(r)
+	if rw == 0 {
+		return 0
+	}
+	joined := cw.joined
+	cw.joined = false
+	switch {
+	case joined:
+		// Part of the previous emoji.
+		return 0
+	case r >= 0x1f3fb && r <= 0x1f3ff && cw.emoji:
+		// Skin tone modifier.
+		return 0
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		// A flag is a pair of regional indicators, each occupying a column.
+		cw.emoji = false
+		return 1
+	}
+	cw.emoji = rw == 2
+	return rw
+}
@@ -0,0 +1,36 @@
+package color
+
+import "testing"
+
+var visibleWidths = map[string]int{
+	"":                           0,
+	"foo":                        3,
+	"\x1b[31mfoo\x1b(B\x1b[m":    3,
+	"\x1b[38;2;1;2;3mfoo\x1b[0m": 3,
+	"世界":                         4,
+	"héllo":                      5,
+	"héllo":                     5,
+	"a\tb\n":                     2,
+	"\x1b]8;;http://x\abar":      3,
+	"\U0001f600":                 2,
+	"foo\x1b":                    3,
+	"e\u0301":                    1,
+	"\u2764\ufe0f":               1,
+	"\U0001f1fa\U0001f1f8":       2,
+	"\U0001f1fa\U0001f1f8\U0001f1ef\U0001f1f5":   4,
+	"\U0001f468\u200d\U0001f469\u200d\U0001f467": 2,
+	"\U0001f44d\U0001f3fd":                       2,
+	"\U0001f3fd":                                 2,
+	"a\u200db":                                   2,
+	"\u200b":                                     0,
+	"\uff21\uff22":                               4,
+}
+
+func TestVisibleWidth(t *testing.T) {
+	t.Parallel()
+	for k, v := range visibleWidths {
+		if r := VisibleWidth(k); r != v {
+			t.Errorf("Expected %d from %q but result was %d", v, k, r)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package color
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WrapText breaks s, which contains escape sequences rather than highlight verbs, into
+// lines that occupy at most width columns on a terminal. Lines are broken at spaces and
+// newlines, the spaces at a break are dropped, and words longer than width are broken
+// between runes. The spaces at the start of a line in s, e.g. an indent, are kept. Each line starts with the SGR sequences that are active at its start and
+// ends with a reset if any are active at its end, so every line renders on its own as it
+// would have in s. Escape sequences occupy no columns and wide runes occupy two.
+// If width is less than 1, lines are only broken at newlines.
+func WrapText(s string, width int) []string {
+	w := &textWrapper{width: width}
+	for _, p := range strings.Split(s, "\n") {
+		w.wrap(p)
+		w.breakLine()
+		w.broken = false
+	}
+	return w.lines
+}
+
+// textWrapper holds the state of WrapText.
+type textWrapper struct {
+	width  int
+	lines  []string
+	line   bytes.Buffer
+	lwidth int      // columns occupied by line
+	active sgrState // SGR state at the end of line
+	spaces int      // spaces to write before the next word
+	broken bool     // line was started by a break within the paragraph
+	word   []piece  // the word being scanned
+	wwidth int      // columns occupied by word
+	cw     clusterWidth
+}
+
+// piece is a rune or an escape sequence in a word.
+type piece struct {
+	s      string
+	width  int
+	escape bool
+}
+
+// wrap wraps the paragraph p, which has no newlines.
+func (w *textWrapper) wrap(p string) {
+	for i := 0; i < len(p); {
+		if p[i] == '\x1b' {
+			if n := escapeLen(p[i:]); n > 0 {
+				w.word = append(w.word, piece{p[i : i+n], 0, true})
+				i += n
+				continue
+			}
+		}
+		r, n := utf8.DecodeRuneInString(p[i:])
+		if r == ' ' {
+			w.endWord()
+			w.spaces++
+			w.cw = clusterWidth{}
+		} else {
+			rw := w.cw.width(r)
+			w.word = append(w.word, piece{p[i : i+n], rw, false})
+			w.wwidth += rw
+		}
+		i += n
+	}
+	w.endWord()
+}
+
+// endWord writes the word to the line, breaking the line first if it does not fit.
+func (w *textWrapper) endWord() {
+	if len(w.word) == 0 {
+		return
+	}
+	if w.width > 0 && w.lwidth > 0 && w.lwidth+w.spaces+w.wwidth > w.width {
+		w.breakLine()
+		w.broken = true
+	}
+	if w.lwidth > 0 || !w.broken {
+		w.line.WriteString(strings.Repeat(" ", w.spaces))
+		w.lwidth += w.spaces
+	}
+	w.spaces = 0
+	for _, p := range w.word {
+		if w.width > 0 && !p.escape && w.lwidth > 0 && w.lwidth+p.width > w.width {
+			// The word is longer than a line.
+			w.breakLine()
+			w.broken = true
+		}
+		w.line.WriteString(p.s)
+		w.lwidth += p.width
+		if p.escape {
+			w.track(p.s)
+		}
+	}
+	w.word = w.word[:0]
+	w.wwidth = 0
+}
+
+// track records the effect of the escape sequence e on the active SGR sequences.
+func (w *textWrapper) track(e string) {
+	w.active.track(e)
+}
+
+// sgrState is the state of the attributes after a series of SGR sequences. The sequences
+// are folded rather than kept, so its size does not grow with their number: the attributes
+// that a Style represents are kept in one and the others, e.g. italic or an underline
+// color, as the last parameter that set each of them.
+type sgrState struct {
+	st    Style
+	extra []sgrParam
+}
+
+// sgrParam is a parameter that sets an attribute a Style does not represent.
+type sgrParam struct {
+	code  int    // code of the attribute, e.g. 3 for italic
+	param string // the parameter with its arguments, e.g. "58;5;208"
+}
+
+// track updates s with the effect of the escape sequence e, which is ignored unless
+// it is a SGR sequence.
+func (s *sgrState) track(e string) {
+	params, n := sgrAt(e)
+	if n == 0 {
+		return
+	}
+	s.st = s.st.apply(params)
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		c := codes[i]
+		sub := strings.IndexByte(c, ':')
+		if sub != -1 {
+			c = c[:sub]
+		}
+		code, err := strconv.Atoi(c)
+		if c == "" {
+			code, err = 0, nil
+		}
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			s.extra = s.extra[:0]
+		case (code == 38 || code == 48 || code == 58) && sub == -1:
+			// Skip the arguments, 5;n or 2;r;g;b.
+			end := i + 1
+			if end < len(codes) && codes[end] == "5" {
+				end += 2
+			} else if end < len(codes) && codes[end] == "2" {
+				end += 4
+			}
+			if end >= len(codes) {
+				end = len(codes) - 1
+			}
+			if code == 58 {
+				s.set(58, strings.Join(codes[i:end+1], ";"))
+			}
+			i = end
+		case code == 58:
+			s.set(58, codes[i])
+		case code == 4 && sub != -1 && codes[i][sub+1:] != "0" && codes[i][sub+1:] != "1":
+			// An underline style.
+			s.set(4, codes[i])
+		case code == 4 || code == 24:
+			s.unset(4)
+		case code == 21:
+			// A double underline.
+			s.set(4, codes[i])
+		case code == 23:
+			s.unset(3, 20)
+		case code == 28 || code == 29 || code == 55:
+			s.unset(code - 20)
+		case code == 54:
+			s.unset(51, 52)
+		case code == 59:
+			s.unset(58)
+		case code == 65:
+			s.unset(60, 61, 62, 63, 64)
+		case code == 10:
+			// The primary font.
+			s.unset(11)
+		case code >= 11 && code <= 19:
+			// All the alternative fonts replace each other.
+			s.set(11, codes[i])
+		case code == 3 || code == 8 || code == 9 || code == 20 ||
+			code >= 51 && code <= 53 || code >= 60 && code <= 64 || code >= 73 && code <= 75:
+			s.set(code, codes[i])
+		}
+	}
+}
+
+// set records param as the parameter that last set the attribute with code.
+func (s *sgrState) set(code int, param string) {
+	for i := range s.extra {
+		if s.extra[i].code == code {
+			s.extra[i].param = param
+			return
+		}
+	}
+	s.extra = append(s.extra, sgrParam{code, param})
+}
+
+// unset forgets the parameters that set the attributes with codes.
+func (s *sgrState) unset(codes ...int) {
+	extra := s.extra[:0]
+	for _, p := range s.extra {
+		keep := true
+		for _, c := range codes {
+			if p.code == c {
+				keep = false
+			}
+		}
+		if keep {
+			extra = append(extra, p)
+		}
+	}
+	s.extra = extra
+}
+
+// clone returns a copy of s that does not share its parameters.
+func (s *sgrState) clone() sgrState {
+	return sgrState{s.st, append([]sgrParam(nil), s.extra...)}
+}
+
+// active returns true if any attributes are set.
+func (s *sgrState) active() bool {
+	return !s.st.isDefault() || len(s.extra) > 0
+}
+
+// sgr returns the SGR sequence that sets the attributes of s from the default state,
+// or an empty string if none are set.
+func (s *sgrState) sgr() string {
+	seq := s.st.Diff(Style{})
+	if len(s.extra) == 0 {
+		return seq
+	}
+	params := make([]string, len(s.extra))
+	for i, p := range s.extra {
+		params[i] = p.param
+	}
+	if seq == "" {
+		return "\x1b[" + strings.Join(params, ";") + "m"
+	}
+	return seq[:len(seq)-1] + ";" + strings.Join(params, ";") + "m"
+}
+
+// breakLine ends the line and starts the next one with the active SGR sequences.
+func (w *textWrapper) breakLine() {
+	if w.active.active() {
+		w.line.WriteString(Reset)
+	}
+	w.lines = append(w.lines, w.line.String())
+	w.line.Reset()
+	w.line.WriteString(w.active.sgr())
+	w.lwidth = 0
+	w.spaces = 0
+}
+
+// NewWrapWriter returns an io.Writer that writes to w but breaks lines that would occupy
+// more than width columns on a terminal by inserting newlines. Unlike WrapText, it breaks
+// lines between runes wherever they reach width rather than at spaces. Like WrapText, an
+// inserted break is preceded by a reset if any SGR sequences are active and followed by
+// the active ones again. Escape sequences and runes split across writes are held back
+// until they are complete, except that an incomplete escape sequence longer than 256
+// bytes is written as it is. A carriage return starts the line over.
+// If width is less than 1, no lines are broken.
+// The returned writer is not safe for concurrent use.
+func NewWrapWriter(w io.Writer, width int) io.Writer {
+	return &wrapWriter{w: w, width: width}
+}
+
+// wrapWriter breaks the lines written to w.
+type wrapWriter struct {
+	w      io.Writer
+	width  int
+	col    int      // columns occupied by the current line
+	active sgrState // SGR state at the end of the output
+	buf    []byte   // incomplete escape sequence or rune held back
+	out    []byte   // reused for the output
+	cw     clusterWidth
+
+	// Set by Printer.SetIndent to indent the lines that are broken.
+	indent      string
+	indentWidth int
+}
+
+func (ww *wrapWriter) Write(p []byte) (n int, err error) {
+	s := string(append(ww.buf, p...))
+	ww.buf = ww.buf[:0]
+	out := ww.out[:0]
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\x1b':
+			if l := escapeLen(s[i:]); l > 0 {
+				out = append(out, s[i:i+l]...)
+				ww.active.track(s[i : i+l])
+				i += l
+				continue
+			}
+			if escapePrefix(s[i:]) {
+				if len(s)-i <= maxEscapePrefix {
+					ww.buf = append(ww.buf, s[i:]...)
+				} else {
+					// Too long to hold back, write it as it is.
+					out = append(out, s[i:]...)
+				}
+				i = len(s)
+				continue
+			}
+		case '\n', '\r':
+			ww.col = 0
+			ww.cw = clusterWidth{}
+			out = append(out, s[i])
+			i++
+			continue
+		}
+		if !utf8.FullRuneInString(s[i:]) {
+			ww.buf = append(ww.buf, s[i:]...)
+			break
+		}
+		r, l := utf8.DecodeRuneInString(s[i:])
+		rw := ww.cw.width(r)
+		if ww.width > 0 && ww.col > 0 && ww.col+rw > ww.width {
+			if ww.active.active() {
+				out = append(out, Reset...)
+			}
+			out = append(out, '\n')
+			out = append(out, ww.indent...)
+			out = append(out, ww.active.sgr()...)
+			ww.col = ww.indentWidth
+		}
+		out = append(out, s[i:i+l]...)
+		ww.col += rw
+		i += l
+	}
+	ww.out = out
+	if len(out) > 0 {
+		if _, err := ww.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
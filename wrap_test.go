@@ -0,0 +1,104 @@
+package color
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var wrapped = []struct {
+	s     string
+	width int
+	lines []string
+}{
+	{"", 10, []string{""}},
+	{"foo bar baz", 7, []string{"foo bar", "baz"}},
+	{"foo  bar   baz", 8, []string{"foo  bar", "baz"}},
+	{"foo\n\nbar", 10, []string{"foo", "", "bar"}},
+	{"foobarbaz qux", 4, []string{"foob", "arba", "z", "qux"}},
+	{"世界世界 ab", 3, []string{"世", "界", "世", "界", "ab"}},
+	{"\x1b[31mfoo bar\x1b[0m baz", 3, []string{"\x1b[31mfoo\x1b[0m", "\x1b[31mbar\x1b[0m", "baz"}},
+	{"\x1b[31m\x1b[1mfoo\nbar", 10, []string{"\x1b[31m\x1b[1mfoo\x1b[0m", "\x1b[1;31mbar\x1b[0m"}},
+	{"\x1b[1mfoo\x1b[m\x1b[4m bar", 3, []string{"\x1b[1mfoo\x1b[m\x1b[4m\x1b[0m", "\x1b[4mbar\x1b[0m"}},
+	{"\x1b]0;t\x07foo bar", 3, []string{"\x1b]0;t\x07foo", "bar"}},
+	{"foo bar", 0, []string{"foo bar"}},
+	{"  foo\n    bar baz", 10, []string{"  foo", "    bar", "baz"}},
+	{"\x1b[1m  foo", 10, []string{"\x1b[1m  foo\x1b[0m"}},
+	{"\U0001f468\u200d\U0001f469\u200d\U0001f467 \U0001f44d\U0001f3fd ab", 5, []string{"\U0001f468\u200d\U0001f469\u200d\U0001f467 \U0001f44d\U0001f3fd", "ab"}},
+}
+
+func TestWrapText(t *testing.T) {
+	t.Parallel()
+	for _, tc := range wrapped {
+		if r := WrapText(tc.s, tc.width); !reflect.DeepEqual(r, tc.lines) {
+			t.Errorf("Expected %q from %q but result was %q", tc.lines, tc.s, r)
+		}
+	}
+}
+
+var wrapWrites = []struct {
+	width  int
+	writes []string
+	exp    string
+}{
+	{5, []string{"0123456789ab"}, "01234\n56789\nab"},
+	{5, []string{"012", "3456\n01", "234"}, "01234\n56\n01234"},
+	{4, []string{"\x1b[31mab", "cdef\x1b[0mgh"}, "\x1b[31mabcd" + Reset + "\n\x1b[31mef\x1b[0mgh"},
+	{3, []string{"ab\x1b[", "1mcd"}, "ab\x1b[1mc" + Reset + "\n\x1b[1md"},
+	{3, []string{"a\xe4\xb8", "\x96b"}, "a\xe4\xb8\x96\nb"},
+	{3, []string{"abc\rdef"}, "abc\rdef"},
+	{0, []string{"0123456789"}, "0123456789"},
+	{4, []string{"ab\x1b]0;", strings.Repeat("x", 200), strings.Repeat("x", 200)}, "ab\x1b]0;" + strings.Repeat("x", 400)},
+}
+
+func TestWrapWriter(t *testing.T) {
+	t.Parallel()
+	for _, tc := range wrapWrites {
+		var b bytes.Buffer
+		w := NewWrapWriter(&b, tc.width)
+		for _, s := range tc.writes {
+			if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+				t.Errorf("Expected %d bytes written but result was %d, %v", len(s), n, err)
+			}
+		}
+		if b.String() != tc.exp {
+			t.Errorf("Expected %q from %q but result was %q", tc.exp, tc.writes, b.String())
+		}
+	}
+}
+
+var sgrStates = []struct {
+	seqs []string
+	exp  string
+}{
+	{[]string{"\x1b[31m", "\x1b[1m"}, "\x1b[1;31m"},
+	{[]string{"\x1b[3m", "\x1b[23m", "\x1b[58;5;208m"}, "\x1b[58;5;208m"},
+	{[]string{"\x1b[3;58:2::1:2:3m", "\x1b[59m"}, "\x1b[3m"},
+	{[]string{"\x1b[38:5:208m", "\x1b[48:2::1:2:3m"}, "\x1b[38;5;208;48;2;1;2;3m"},
+	{[]string{"\x1b[4:3m", "\x1b[24m", "\x1b[12m", "\x1b[13m"}, "\x1b[13m"},
+	{[]string{"\x1b[9;1m", "\x1b[0m", "\x1b[K"}, ""},
+}
+
+func TestSGRState(t *testing.T) {
+	t.Parallel()
+	for _, tc := range sgrStates {
+		var s sgrState
+		for _, e := range tc.seqs {
+			s.track(e)
+		}
+		if r := s.sgr(); r != tc.exp {
+			t.Errorf("Expected %q from %q but result was %q", tc.exp, tc.seqs, r)
+		}
+	}
+}
+
+func TestWrapTextBounded(t *testing.T) {
+	t.Parallel()
+	s := strings.Repeat("\x1b[31m\x1b[32m\x1b[3m\x1b[58;5;1m", 1000) + "abcd"
+	lines := WrapText(s, 2)
+	exp := "\x1b[32;3;58;5;1mcd\x1b[0m"
+	if len(lines) != 2 || lines[1] != exp {
+		t.Errorf("Expected %q as the last line but result was %q", exp, lines)
+	}
+}